@@ -82,9 +82,6 @@ func (m *Map) Get(key string) string {
 	idx := sort.Search(len(m.keys), func(i int) bool {
 		return m.keys[i] >= hash
 	})
-	fmt.Println(idx % len(m.keys))
-	fmt.Println(m.keys[idx%len(m.keys)])
-	fmt.Println(m.hashMap[m.keys[idx%len(m.keys)]])
 	return m.hashMap[m.keys[idx%len(m.keys)]]
 }
 
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	geerpc "gee-web/gee-rpc/03-service"
+	"gee-web/gee-rpc/03-service/codec"
+	"log"
+	"net"
+	"time"
+)
+
+// TODO 在这里我们通过反射实现了服务注册，将结构体中满足 func (t *T) MethodName(argType T1, replyType *T2) error 的方法发布为服务，
+//
+// TODO 服务端根据请求头中的 ServiceMethod 找到对应的方法，按方法的参数类型解码请求参数，调用方法并回复返回值或错误。
+//
+// startServer 启动一个RPC服务器，监听一个随机端口，并将绑定的地址通过addr通道发送给调用方。
+// 参数：
+//
+//	addr - 用于传递服务器绑定地址的通道。
+func startServer(addr chan string) {
+	// 注册 Foo 服务
+	var foo Foo
+	if err := geerpc.Register(&foo); err != nil {
+		log.Fatal("register error:", err)
+	}
+	// 监听一个随机可用的TCP端口
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		log.Fatal("network error:", err)
+	}
+	log.Println("start rpc server on", l.Addr())
+	// 将服务器绑定地址发送到addr通道
+	addr <- l.Addr().String()
+	// 接受客户端的连接请求
+	geerpc.Accept(l)
+}
+
+// Foo 是示例服务。
+type Foo int
+
+// Args 是 Foo.Sum 的参数。
+type Args struct{ Num1, Num2 int }
+
+// Sum 返回两个数的和。
+func (f Foo) Sum(args Args, reply *int) error {
+	*reply = args.Num1 + args.Num2
+	return nil
+}
+
+// main 函数是程序的入口点，启动一个RPC服务器并模拟一个简单的客户端与之通信。
+func main() {
+	// 设置日志格式和输出位置
+	log.SetFlags(0)
+	addr := make(chan string)
+	// 启动一个RPC服务器
+	go startServer(addr)
+
+	// 模拟一个简单的geerpc客户端，连接到刚启动的服务器
+	conn, _ := net.Dial("tcp", <-addr)
+	// 关闭连接
+	defer func() { _ = conn.Close() }()
+
+	time.Sleep(time.Second)
+	// 向服务器发送默认选项（使用json编码器发送默认选项）
+	_ = json.NewEncoder(conn).Encode(geerpc.DefaultOption)
+	// 创建一个Gob编解码器
+	cc := codec.NewGobCodec(conn)
+	// 循环发送请求并接收响应
+	for i := 0; i < 5; i++ {
+		// 发送请求
+		h := &codec.Header{
+			ServiceMethod: "Foo.Sum", // ServiceMethod 是服务名和方法名
+			Seq:           uint64(i), // 请求序号
+		}
+		args := Args{Num1: i, Num2: i * i}
+		// 编码请求
+		_ = cc.Write(h, args)
+		// 读取响应
+		_ = cc.ReadHeader(h)
+		var reply int
+		// 解码响应
+		_ = cc.ReadBody(&reply)
+		log.Printf("%d + %d = %d", args.Num1, args.Num2, reply)
+	}
+}
@@ -0,0 +1,272 @@
+package geerpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gee-web/gee-rpc/03-service/codec"
+	"io"
+	"log"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MagicNumber 是用于标识 geerpc 请求的魔数。
+const MagicNumber = 0x3bef5c
+
+// Option 定义了 RPC 请求的选项。
+type Option struct {
+	MagicNumber int        // 魔数，用于标记这是一个 geerpc 请求
+	CodecType   codec.Type // 客户端可以选择不同的编解码器来编码请求体
+}
+
+// DefaultOption 是默认的 Option 实例。
+var DefaultOption = &Option{
+	MagicNumber: MagicNumber,   // 魔数，用于标记这是一个 geerpc 请求
+	CodecType:   codec.GobType, // 客户端可以选择不同的编解码器来编码请求体
+}
+
+// Server 表示一个 RPC 服务器。
+type Server struct {
+	serviceMap sync.Map // 服务名到 *service 的映射
+}
+
+// ErrNotFound 表示找不到请求的服务或方法。
+var ErrNotFound = errors.New("rpc server: can't find service or method")
+
+// NewServer 返回一个新的 Server 实例。
+func NewServer() *Server {
+	return &Server{}
+}
+
+// DefaultServer 是默认的 Server 实例。
+var DefaultServer = NewServer()
+
+// ServeConn 在单个连接上运行服务器。
+// ServeConn 会阻塞，直到客户端断开连接。
+func (server *Server) ServeConn(conn io.ReadWriteCloser) {
+	// 确保连接被关闭
+	defer func() { _ = conn.Close() }()
+	var opt Option
+	// 从连接中读取选项
+	if err := json.NewDecoder(conn).Decode(&opt); err != nil {
+		log.Println("rpc server: options error: ", err)
+		return
+	}
+	// 检查选项的魔数
+	if opt.MagicNumber != MagicNumber {
+		log.Printf("rpc server: invalid magic number %x", opt.MagicNumber)
+		return
+	}
+	// 根据选项的编解码器类型创建编解码器
+	f := codec.NewCodecFuncMap[opt.CodecType]
+	if f == nil {
+		log.Printf("rpc server: invalid codec type %s", opt.CodecType)
+		return
+	}
+	// 处理连接
+	server.serveCodec(f(conn))
+}
+
+// Register 在服务器中发布接收者 rcvr 中满足条件的方法，服务名为 rcvr 的类型名。
+// 类型名不是导出的名称或服务名已被注册时返回错误。
+func (server *Server) Register(rcvr interface{}) error {
+	s, err := newService(rcvr)
+	if err != nil {
+		return err
+	}
+	if _, dup := server.serviceMap.LoadOrStore(s.name, s); dup {
+		return errors.New("rpc: service already defined: " + s.name)
+	}
+	return nil
+}
+
+// Register 在 DefaultServer 中发布 rcvr 的方法。
+func Register(rcvr interface{}) error {
+	return DefaultServer.Register(rcvr)
+}
+
+// findService 根据 "Service.Method" 查找服务和方法。
+func (server *Server) findService(serviceMethod string) (svc *service, mtype *methodType, err error) {
+	dot := strings.LastIndex(serviceMethod, ".")
+	if dot < 0 {
+		return nil, nil, fmt.Errorf("rpc server: service/method request ill-formed: %s", serviceMethod)
+	}
+	serviceName, methodName := serviceMethod[:dot], serviceMethod[dot+1:]
+	svci, ok := server.serviceMap.Load(serviceName)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w %s", ErrNotFound, serviceMethod)
+	}
+	svc = svci.(*service)
+	mtype = svc.method[methodName]
+	if mtype == nil {
+		return nil, nil, fmt.Errorf("%w %s", ErrNotFound, serviceMethod)
+	}
+	return svc, mtype, nil
+}
+
+// MethodInfo 描述一个已注册的方法。
+type MethodInfo struct {
+	Name      string       // "Service.Method"
+	ArgType   reflect.Type // 参数类型
+	ReplyType reflect.Type // 返回值类型，总是指针
+}
+
+// Methods 返回所有已注册的方法，按名称排序。
+func (server *Server) Methods() []MethodInfo {
+	var methods []MethodInfo
+	server.serviceMap.Range(func(_, v interface{}) bool {
+		svc := v.(*service)
+		for name, m := range svc.method {
+			methods = append(methods, MethodInfo{Name: svc.name + "." + name, ArgType: m.ArgType, ReplyType: m.ReplyType})
+		}
+		return true
+	})
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name })
+	return methods
+}
+
+// Call 在当前 goroutine 中调用 serviceMethod，供 HTTP 网关等不使用 geerpc 协议的调用方使用。
+// readArgs 接收指向参数实例的指针并填充参数，与 Codec.ReadBody 的用法相同；它返回的错误会原样返回。
+// 返回值是指向结果的指针；找不到方法时返回的错误包装了 ErrNotFound。
+func (server *Server) Call(serviceMethod string, readArgs func(argv interface{}) error) (reply interface{}, err error) {
+	svc, mtype, err := server.findService(serviceMethod)
+	if err != nil {
+		return nil, err
+	}
+	argv, replyv := mtype.newArgv(), mtype.newReplyv()
+	if err = readArgs(argvPointer(argv)); err != nil {
+		return nil, err
+	}
+	if err = svc.call(mtype, argv, replyv); err != nil {
+		return nil, err
+	}
+	return replyv.Interface(), nil
+}
+
+// argvPointer 返回指向参数的指针，ReadBody 等解码函数需要指针。
+func argvPointer(argv reflect.Value) interface{} {
+	if argv.Type().Kind() != reflect.Ptr {
+		return argv.Addr().Interface()
+	}
+	return argv.Interface()
+}
+
+// invalidRequest 是当发生错误时响应参数的占位符。
+var invalidRequest = struct{}{}
+
+// serveCodec 处理通过指定编解码器发送的请求。
+func (server *Server) serveCodec(cc codec.Codec) {
+	sending := new(sync.Mutex) // 确保发送完整的响应
+	wg := new(sync.WaitGroup)  // 等待所有请求处理完成
+	for {
+		// 读取请求
+		req, err := server.readRequest(cc)
+		if err != nil {
+			if req == nil {
+				break // 无法恢复，关闭连接
+			}
+			req.h.Error = err.Error()
+			// 发送错误响应
+			server.sendResponse(cc, req.h, invalidRequest, sending)
+			continue
+		}
+		wg.Add(1)
+		// 处理请求
+		go server.handleRequest(cc, req, sending, wg)
+	}
+	wg.Wait()
+	_ = cc.Close()
+}
+
+// request 存储调用的所有信息。
+type request struct {
+	h      *codec.Header // 请求头
+	argv   reflect.Value // 请求参数
+	replyv reflect.Value // 响应值
+	mtype  *methodType   // 调用的方法
+	svc    *service      // 方法所属的服务
+}
+
+// readRequestHeader 从编解码器中读取请求头。
+func (server *Server) readRequestHeader(cc codec.Codec) (*codec.Header, error) {
+	var h codec.Header
+	// 从连接中读取请求头。
+	if err := cc.ReadHeader(&h); err != nil {
+		if err != io.EOF && err != io.ErrUnexpectedEOF {
+			log.Println("rpc server: read header error:", err)
+		}
+		return nil, err
+	}
+	return &h, nil
+}
+
+// readRequest 从编解码器中读取完整的请求。
+func (server *Server) readRequest(cc codec.Codec) (*request, error) {
+	// 读取请求头
+	h, err := server.readRequestHeader(cc)
+	if err != nil {
+		return nil, err
+	}
+	req := &request{h: h}
+	req.svc, req.mtype, err = server.findService(h.ServiceMethod)
+	if err != nil {
+		// 丢弃请求参数，保证下一个请求可以正确读取
+		_ = cc.ReadBody(nil)
+		return req, err
+	}
+	req.argv = req.mtype.newArgv()
+	req.replyv = req.mtype.newReplyv()
+	// 从连接中读取请求参数
+	if err = cc.ReadBody(argvPointer(req.argv)); err != nil {
+		log.Println("rpc server: read argv err:", err)
+		return req, err
+	}
+	return req, nil
+}
+
+// sendResponse 使用指定的编解码器发送响应。
+func (server *Server) sendResponse(cc codec.Codec, h *codec.Header, body interface{}, sending *sync.Mutex) {
+	sending.Lock()
+	defer sending.Unlock()
+	// 使用指定的编解码器发送响应
+	if err := cc.Write(h, body); err != nil {
+		log.Println("rpc server: write response error:", err)
+	}
+}
+
+// handleRequest 处理单个请求。
+func (server *Server) handleRequest(cc codec.Codec, req *request, sending *sync.Mutex, wg *sync.WaitGroup) {
+	defer wg.Done()
+	// 调用注册的 RPC 方法
+	if err := req.svc.call(req.mtype, req.argv, req.replyv); err != nil {
+		req.h.Error = err.Error()
+		server.sendResponse(cc, req.h, invalidRequest, sending)
+		return
+	}
+	// 发送响应
+	server.sendResponse(cc, req.h, req.replyv.Interface(), sending)
+}
+
+// Accept 接受监听器上的连接并为每个传入连接提供服务。
+func (server *Server) Accept(lis net.Listener) {
+	for {
+		// 接受客户端的连接请求
+		conn, err := lis.Accept()
+		if err != nil {
+			log.Println("rpc server: accept error:", err)
+			return
+		}
+		// 为每个连接提供服务
+		go server.ServeConn(conn)
+	}
+}
+
+// Accept 接受监听器上的连接并为每个传入连接提供服务。
+func Accept(lis net.Listener) {
+	// 将服务器绑定地址发送到addr通道
+	DefaultServer.Accept(lis)
+}
@@ -0,0 +1,105 @@
+package geerpc
+
+import (
+	"fmt"
+	"go/ast"
+	"log"
+	"reflect"
+	"sync/atomic"
+)
+
+// methodType 保存一个可以被远程调用的方法的完整信息。
+type methodType struct {
+	method    reflect.Method // 方法本身
+	ArgType   reflect.Type   // 第一个参数的类型
+	ReplyType reflect.Type   // 第二个参数的类型，必须是指针
+	numCalls  uint64         // 方法被调用的次数
+}
+
+// NumCalls 返回方法被调用的次数。
+func (m *methodType) NumCalls() uint64 {
+	return atomic.LoadUint64(&m.numCalls)
+}
+
+// newArgv 创建参数的实例，参数可以是指针类型，也可以是值类型。
+func (m *methodType) newArgv() reflect.Value {
+	if m.ArgType.Kind() == reflect.Ptr {
+		return reflect.New(m.ArgType.Elem())
+	}
+	return reflect.New(m.ArgType).Elem()
+}
+
+// newReplyv 创建返回值的实例，map 和 slice 需要初始化。
+func (m *methodType) newReplyv() reflect.Value {
+	replyv := reflect.New(m.ReplyType.Elem())
+	switch m.ReplyType.Elem().Kind() {
+	case reflect.Map:
+		replyv.Elem().Set(reflect.MakeMap(m.ReplyType.Elem()))
+	case reflect.Slice:
+		replyv.Elem().Set(reflect.MakeSlice(m.ReplyType.Elem(), 0, 0))
+	}
+	return replyv
+}
+
+// service 是一个注册的服务，即一个结构体及其符合条件的方法。
+type service struct {
+	name   string                 // 结构体的名称
+	typ    reflect.Type           // 结构体的类型
+	rcvr   reflect.Value          // 结构体的实例本身，调用方法时作为第 0 个参数
+	method map[string]*methodType // 结构体中所有符合条件的方法
+}
+
+// newService 通过反射创建服务，rcvr 的类型名不是导出的名称时返回错误。
+func newService(rcvr interface{}) (*service, error) {
+	s := new(service)
+	s.rcvr = reflect.ValueOf(rcvr)
+	s.name = reflect.Indirect(s.rcvr).Type().Name()
+	s.typ = reflect.TypeOf(rcvr)
+	if !ast.IsExported(s.name) {
+		return nil, fmt.Errorf("rpc server: %s is not a valid service name", s.name)
+	}
+	s.registerMethods()
+	return s, nil
+}
+
+// registerMethods 过滤出符合条件的方法：
+// 两个导出或内置类型的参数（第二个为指针），一个 error 类型的返回值，即 func (t *T) MethodName(argType T1, replyType *T2) error。
+func (s *service) registerMethods() {
+	s.method = make(map[string]*methodType)
+	for i := 0; i < s.typ.NumMethod(); i++ {
+		method := s.typ.Method(i)
+		mType := method.Type
+		if mType.NumIn() != 3 || mType.NumOut() != 1 {
+			continue
+		}
+		if mType.Out(0) != reflect.TypeOf((*error)(nil)).Elem() {
+			continue
+		}
+		argType, replyType := mType.In(1), mType.In(2)
+		if !isExportedOrBuiltinType(argType) || !isExportedOrBuiltinType(replyType) || replyType.Kind() != reflect.Ptr {
+			continue
+		}
+		s.method[method.Name] = &methodType{
+			method:    method,
+			ArgType:   argType,
+			ReplyType: replyType,
+		}
+		log.Printf("rpc server: register %s.%s\n", s.name, method.Name)
+	}
+}
+
+// call 通过反射调用方法。
+func (s *service) call(m *methodType, argv, replyv reflect.Value) error {
+	atomic.AddUint64(&m.numCalls, 1)
+	f := m.method.Func
+	returnValues := f.Call([]reflect.Value{s.rcvr, argv, replyv})
+	if errInter := returnValues[0].Interface(); errInter != nil {
+		return errInter.(error)
+	}
+	return nil
+}
+
+// isExportedOrBuiltinType 判断类型是否是导出类型或内置类型。
+func isExportedOrBuiltinType(t reflect.Type) bool {
+	return ast.IsExported(t.Name()) || t.PkgPath() == ""
+}
@@ -0,0 +1,52 @@
+package gee
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+)
+
+// AuthUserKey 是 BasicAuth 认证通过后用户名在 Context 中保存时使用的键。
+const AuthUserKey = "user"
+
+// Accounts 是用户名到密码的映射，用于 BasicAuth。
+type Accounts map[string]string
+
+// BasicAuth 返回 HTTP Basic 认证中间件，realm 为 "Authorization Required"。
+// 参数:
+//   - accounts: 允许访问的账号。
+func BasicAuth(accounts Accounts) HandlerFunc {
+	return BasicAuthForRealm(accounts, "")
+}
+
+// BasicAuthForRealm 返回 HTTP Basic 认证中间件。
+// 认证通过后可以通过 c.GetString(AuthUserKey) 获取用户名；认证失败时返回 401 并带上 WWW-Authenticate 头。
+// 参数:
+//   - accounts: 允许访问的账号。
+//   - realm: 认证域，为空时使用 "Authorization Required"。
+func BasicAuthForRealm(accounts Accounts, realm string) HandlerFunc {
+	if realm == "" {
+		realm = "Authorization Required"
+	}
+	challenge := "Basic realm=" + strconv.Quote(realm) + `, charset="UTF-8"`
+	// 预先计算密码的摘要，比较摘要可以让比较耗时与密码长度无关
+	digests := make(map[string][sha256.Size]byte, len(accounts))
+	for user, password := range accounts {
+		digests[user] = sha256.Sum256([]byte(password))
+	}
+	return func(c *Context) {
+		user, password, ok := c.Req.BasicAuth()
+		if ok {
+			expected, found := digests[user]
+			actual := sha256.Sum256([]byte(password))
+			// 用户不存在时同样进行一次比较，避免通过响应时间探测用户名
+			if subtle.ConstantTimeCompare(expected[:], actual[:]) == 1 && found {
+				c.Set(AuthUserKey, user)
+				return
+			}
+		}
+		c.SetHeader("WWW-Authenticate", challenge)
+		c.Fail(http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+	}
+}
@@ -0,0 +1,39 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBasicAuth(t *testing.T) {
+	r := New()
+	r.Use(BasicAuthForRealm(Accounts{"admin": "secret"}, "gee"))
+	r.GET("/admin", func(c *Context) {
+		c.String(http.StatusOK, "hello %s", c.GetString(AuthUserKey))
+	})
+
+	cases := []struct {
+		user, password string
+		code           int
+	}{
+		{"admin", "secret", http.StatusOK},
+		{"admin", "wrong", http.StatusUnauthorized},
+		{"nobody", "secret", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.SetBasicAuth(tc.user, tc.password)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.code {
+			t.Fatalf("%s:%s expect %d, got %d", tc.user, tc.password, tc.code, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	if w.Header().Get("WWW-Authenticate") != `Basic realm="gee", charset="UTF-8"` {
+		t.Fatalf("unexpected challenge %q", w.Header().Get("WWW-Authenticate"))
+	}
+}
@@ -0,0 +1,225 @@
+package gee
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bind 将请求绑定到 obj，obj 必须是指向结构体的指针。
+// 请求体按 Content-Type 解析：JSON 按 json 标签绑定，表单按 form 标签（没有时使用 json 标签）绑定。
+// 之后绑定参数，参数优先于请求体中的同名字段：
+//   - `path:"id"` 绑定路径参数
+//   - `query:"page"` 绑定查询参数
+//   - `header:"X-Request-ID"` 绑定请求头
+//
+// 参数标签加上 ",required" 表示必需，例如 `query:"page,required"`。
+// 绑定失败时返回 *HTTPError，状态码为 400 或 415。
+func (c *Context) Bind(obj interface{}) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("gee: Bind requires a non-nil pointer, got %T", obj)
+	}
+	// obj 可能是指向指针的指针，例如 Handle 的 Req 为 *T 时，绑定到最终指向的值
+	for v.Elem().Kind() == reflect.Ptr {
+		if v.Elem().IsNil() {
+			v.Elem().Set(reflect.New(v.Elem().Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if err := c.bindBody(v.Interface()); err != nil {
+		return err
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	return c.bindParams(v)
+}
+
+// bindBody 按 Content-Type 解析请求体，没有请求体时直接返回。
+func (c *Context) bindBody(obj interface{}) error {
+	if c.Req.Body == nil || c.Req.Body == http.NoBody || c.Req.ContentLength == 0 {
+		return nil
+	}
+	contentType, _, _ := mime.ParseMediaType(c.Req.Header.Get("Content-Type"))
+	switch {
+	case contentType == "application/json" || strings.HasSuffix(contentType, "+json") || contentType == "":
+		if err := json.NewDecoder(c.Req.Body).Decode(obj); err != nil && err != io.EOF {
+			return bodyError("invalid JSON body", err)
+		}
+	case contentType == "application/x-www-form-urlencoded" || contentType == "multipart/form-data":
+		if contentType == "multipart/form-data" {
+			if _, err := c.MultipartForm(); err != nil {
+				return bodyError("invalid form", err)
+			}
+		} else if err := c.Req.ParseForm(); err != nil {
+			return bodyError("invalid form", err)
+		}
+		v := reflect.ValueOf(obj).Elem()
+		if v.Kind() == reflect.Struct {
+			return bindValues(v, "form", func(name string) []string { return c.Req.PostForm[name] })
+		}
+	default:
+		return NewHTTPError(http.StatusUnsupportedMediaType, "unsupported Content-Type: "+contentType)
+	}
+	return nil
+}
+
+// bodyError 将读取请求体的错误转换为 HTTPError，请求体超过 BodyLimit 或解压限制时返回 413，否则返回 400。
+func bodyError(message string, err error) *HTTPError {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return NewHTTPError(http.StatusRequestEntityTooLarge, "")
+	}
+	return NewHTTPError(http.StatusBadRequest, message+": "+err.Error())
+}
+
+// bindParams 绑定路径参数、查询参数和请求头。
+func (c *Context) bindParams(v reflect.Value) error {
+	query := c.Req.URL.Query()
+	sources := []struct {
+		tag    string
+		lookup func(name string) []string
+	}{
+		{"path", func(name string) []string {
+			if value, ok := c.Params[name]; ok {
+				return []string{value}
+			}
+			return nil
+		}},
+		{"query", func(name string) []string { return query[name] }},
+		{"header", func(name string) []string { return c.Req.Header.Values(name) }},
+	}
+	for _, source := range sources {
+		if err := bindValues(v, source.tag, source.lookup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bindValues 将 lookup 返回的值绑定到带有 tag 标签的字段，嵌入的结构体会被展开。
+// tag 为 "form" 时，没有 form 标签的字段使用 json 标签中的名称。
+func bindValues(v reflect.Value, tag string, lookup func(name string) []string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		spec, ok := f.Tag.Lookup(tag)
+		if !ok && tag == "form" {
+			spec, ok = f.Tag.Lookup("json")
+		}
+		if !ok {
+			if f.Anonymous {
+				fv := v.Field(i)
+				if fv.Kind() == reflect.Ptr {
+					if fv.IsNil() {
+						fv.Set(reflect.New(fv.Type().Elem()))
+					}
+					fv = fv.Elem()
+				}
+				if fv.Kind() == reflect.Struct {
+					if err := bindValues(fv, tag, lookup); err != nil {
+						return err
+					}
+				}
+			}
+			continue
+		}
+		name, opts, _ := strings.Cut(spec, ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		values := lookup(name)
+		if len(values) == 0 {
+			if opts == "required" {
+				return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("missing %s parameter %q", tag, name))
+			}
+			continue
+		}
+		if err := setValue(v.Field(i), values); err != nil {
+			return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid %s parameter %q: %v", tag, name, err))
+		}
+	}
+	return nil
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// setValue 将字符串值转换为字段的类型，切片字段接收所有值，其他字段使用第一个值。
+func setValue(v reflect.Value, values []string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setValue(v.Elem(), values)
+	}
+	if reflect.PtrTo(v.Type()).Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(values[0]))
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		s := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(s.Index(i), []string{value}); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+
+	value := values[0]
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
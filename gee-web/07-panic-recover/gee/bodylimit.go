@@ -0,0 +1,19 @@
+package gee
+
+import "net/http"
+
+// BodyLimit 返回限制请求体大小的中间件。
+// Content-Length 超过 limit 的请求直接返回 413；未声明长度（chunked）的请求体在读取超过 limit 时返回
+// *http.MaxBytesError 错误，并由 net/http 关闭连接。
+// 参数:
+//   - limit: 允许的最大请求体字节数。
+func BodyLimit(limit int64) HandlerFunc {
+	return func(c *Context) {
+		if c.Req.ContentLength > limit {
+			c.SetHeader("Connection", "close")
+			c.Fail(http.StatusRequestEntityTooLarge, http.StatusText(http.StatusRequestEntityTooLarge))
+			return
+		}
+		c.Req.Body = http.MaxBytesReader(c.Writer, c.Req.Body, limit)
+	}
+}
@@ -0,0 +1,376 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	geecache "gee-web/gee-cache/07-proto-buf/gee-cache"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fillHeader 标记由远程节点重新执行的请求，缓存中间件遇到它时直接放行。
+const fillHeader = "X-Gee-Cache-Fill"
+
+// Config 是响应缓存中间件的配置。
+type Config struct {
+	// TTL 是响应没有指定 max-age 时的有效期，同时也是缓存的最长有效期，默认为 1 分钟。
+	// geecache 不支持过期和删除，因此缓存键中带有按 TTL 划分的时间窗口，窗口切换后旧的条目由 LRU 淘汰；
+	// 响应的 max-age 短于 TTL 时，过期后改用与 max-age 等长的时间窗口。
+	TTL time.Duration
+	// Vary 是参与生成缓存键的请求头，例如 "Accept-Encoding"、"Accept-Language"。
+	Vary []string
+	// KeyFunc 生成缓存键，默认为 DefaultKey。
+	KeyFunc KeyFunc
+	// Engine 用于在远程节点请求本节点加载缓存时重新执行请求，为 nil 时只能由发起请求的节点加载。
+	Engine *gee.Engine
+}
+
+// Cache 将 GET 响应缓存在 geecache.Group 中。
+// 本地未命中时由 Group 的 singleflight 合并并发请求，并可以通过 RegisterPeers 注册的节点加载。
+type Cache struct {
+	config  Config
+	group   *geecache.Group
+	mu      sync.Mutex
+	pending map[string]*pending // 正在本节点等待加载的请求
+	now     func() time.Time    // 便于测试替换
+}
+
+// pending 是一个等待加载的请求，加载时直接在它的 Context 上执行后续的处理函数。
+type pending struct {
+	c       *gee.Context
+	claimed bool
+	done    chan struct{}
+	rec     *recorder
+	panic   interface{} // 执行处理函数时发生的 panic，在请求自己的 goroutine 中重新抛出
+}
+
+// entry 是缓存中保存的响应。
+type entry struct {
+	Status  int         `json:"status"`
+	Header  http.Header `json:"header"`
+	Body    []byte      `json:"body"`
+	Stored  time.Time   `json:"stored"`
+	Expires time.Time   `json:"expires"`
+	// TTL 是响应的有效期，短于 Config.TTL 时条目会在时间窗口结束前过期
+	TTL time.Duration `json:"ttl"`
+}
+
+// uncacheableError 表示响应已经生成但不能被缓存。
+// rec 不为 nil 时，singleflight 中同时等待这个键的请求直接使用它，而不是再执行一次处理函数。
+type uncacheableError struct {
+	reason string
+	rec    *recorder
+}
+
+func (e *uncacheableError) Error() string { return "cache: response not cacheable: " + e.reason }
+
+// New 创建名为 name 的 geecache.Group 及其响应缓存。
+// 参数:
+//   - name: 缓存组名称，在所有节点上必须一致。
+//   - cacheBytes: 本地缓存的最大字节数。
+//   - config: 缓存配置。
+func New(name string, cacheBytes int64, config Config) *Cache {
+	if config.TTL <= 0 {
+		config.TTL = time.Minute
+	}
+	if config.KeyFunc == nil {
+		config.KeyFunc = DefaultKey
+	}
+	ch := &Cache{config: config, pending: make(map[string]*pending), now: time.Now}
+	ch.group = geecache.NewGroup(name, cacheBytes, geecache.GetterFunc(ch.load))
+	return ch
+}
+
+// Group 返回底层的 geecache.Group，可以用于注册远程节点。
+func (ch *Cache) Group() *geecache.Group {
+	return ch.group
+}
+
+// Middleware 返回缓存中间件，只缓存 GET 请求。
+// 支持的请求指令：no-store、no-cache 跳过缓存，max-age 限制可接受的缓存时长。
+// 支持的响应指令：no-store、no-cache、private 不缓存，s-maxage、max-age 设置有效期。
+// 带有 Authorization 或 Cookie 请求头（且该请求头不在 Config.Vary 中）的请求跳过缓存。
+// 带有 Set-Cookie、Vary: * 或 Vary 了未配置请求头的响应不会被缓存；
+// 除 Set-Cookie 和 private 的响应外，不能缓存的响应会直接交给同时等待这个键的请求，不会重复执行处理函数。
+// 响应头 X-Cache 表示结果为 HIT、MISS 或 BYPASS。
+func (ch *Cache) Middleware() gee.HandlerFunc {
+	return func(c *gee.Context) {
+		if c.Method != http.MethodGet || c.Req.Header.Get(fillHeader) != "" {
+			return
+		}
+		directives := parseCacheControl(c.Req.Header.Get("Cache-Control"))
+		_, noStore := directives["no-store"]
+		_, noCache := directives["no-cache"]
+		if noStore || noCache || directives["max-age"] == "0" || ch.personalized(c) {
+			c.SetHeader("X-Cache", "BYPASS")
+			return
+		}
+
+		now := ch.now()
+		k := ch.config.KeyFunc(c, ch.config.Vary)
+		var e entry
+		for size := ch.config.TTL; ; size = e.TTL {
+			view, handled, err := ch.get(c, k.encode(now, size))
+			if handled {
+				return
+			}
+			e = entry{}
+			if err != nil || json.Unmarshal(view.ByteSlice(), &e) != nil {
+				c.SetHeader("X-Cache", "MISS")
+				return
+			}
+			if now.Before(e.Expires) {
+				break
+			}
+			// 响应的 max-age 短于时间窗口时，条目会在窗口结束前过期，而 geecache 无法删除或刷新它；
+			// 改用与 max-age 等长的时间窗口，新的条目在所在窗口内一直有效
+			if e.TTL <= 0 || e.TTL >= size {
+				c.SetHeader("X-Cache", "MISS")
+				return
+			}
+		}
+		age := now.Sub(e.Stored)
+		if maxAge, ok := directives["max-age"]; ok {
+			if seconds, err := strconv.Atoi(maxAge); err == nil && age > time.Duration(seconds)*time.Second {
+				c.SetHeader("X-Cache", "MISS")
+				return
+			}
+		}
+		rec := &recorder{header: e.Header, status: e.Status, body: e.Body}
+		rec.writeTo(c, "HIT", age)
+		c.Abort()
+	}
+}
+
+// get 从 Group 中读取 key，本节点等待中的请求会被合并。
+// 当前请求的响应已经在加载时写出（执行了处理函数或复用了不能缓存的响应）时 handled 为 true。
+func (ch *Cache) get(c *gee.Context, key string) (view geecache.ByteView, handled bool, err error) {
+	p := &pending{c: c, done: make(chan struct{})}
+	ch.mu.Lock()
+	_, exists := ch.pending[key]
+	if !exists {
+		ch.pending[key] = p
+	}
+	ch.mu.Unlock()
+
+	view, err = ch.group.Get(key)
+
+	ch.mu.Lock()
+	if !exists && ch.pending[key] == p {
+		delete(ch.pending, key)
+	}
+	claimed := p.claimed
+	ch.mu.Unlock()
+	if claimed {
+		// 后续的处理函数已经在加载时执行过了
+		<-p.done
+		if p.panic != nil {
+			panic(p.panic)
+		}
+		p.rec.writeTo(c, "MISS", 0)
+		return view, true, nil
+	}
+	var uncacheable *uncacheableError
+	if errors.As(err, &uncacheable) && uncacheable.rec != nil {
+		uncacheable.rec.writeTo(c, "MISS", 0)
+		c.Abort()
+		return view, true, nil
+	}
+	return view, false, err
+}
+
+// personalized 判断请求是否带有未参与缓存键的凭据（Authorization 或 Cookie），这样的响应不能在用户之间共享。
+func (ch *Cache) personalized(c *gee.Context) bool {
+	for _, name := range []string{"Authorization", "Cookie"} {
+		if c.Req.Header.Get(name) != "" && !ch.varies(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// load 是 geecache.Group 的 Getter。
+// 本节点有等待中的请求时直接执行它后续的处理函数，否则根据键还原请求并通过 Engine 重新执行。
+func (ch *Cache) load(key string) ([]byte, error) {
+	ch.mu.Lock()
+	p := ch.pending[key]
+	if p != nil {
+		p.claimed = true
+		delete(ch.pending, key)
+	}
+	ch.mu.Unlock()
+
+	rec := newRecorder()
+	if p != nil {
+		c := p.c
+		w := c.Writer
+		c.Writer = rec
+		// panic 不能穿过 singleflight，否则等待同一个键的请求会一直阻塞
+		func() {
+			defer func() {
+				p.panic = recover()
+				c.Writer = w
+				p.rec = rec
+				close(p.done)
+			}()
+			c.Next()
+		}()
+		if p.panic != nil {
+			return nil, fmt.Errorf("cache: panic while loading: %v", p.panic)
+		}
+	} else {
+		if ch.config.Engine == nil {
+			return nil, errors.New("cache: no Engine to replay request")
+		}
+		k, err := parseKey(key)
+		if err != nil {
+			return nil, err
+		}
+		req := k.request()
+		req.Header.Set(fillHeader, "1")
+		if err := serve(ch.config.Engine, rec, req); err != nil {
+			return nil, err
+		}
+	}
+
+	ttl, reason := ch.ttl(rec)
+	if reason != "" {
+		if reason == "Set-Cookie" || reason == "private" {
+			// 针对单个用户的响应，等待中的请求需要各自执行处理函数
+			return nil, &uncacheableError{reason: reason}
+		}
+		return nil, &uncacheableError{reason: reason, rec: rec}
+	}
+	now := ch.now()
+	expires := now.Add(ttl)
+	if end, ok := windowEnd(key); ok && end.Before(expires) {
+		expires = end
+	}
+	return json.Marshal(entry{Status: rec.status, Header: rec.header, Body: rec.body, Stored: now, Expires: expires, TTL: ttl})
+}
+
+// serve 通过 Engine 重新执行请求，并将 panic 转换为错误。
+func serve(engine *gee.Engine, w http.ResponseWriter, req *http.Request) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cache: panic while replaying %s: %v", req.URL, r)
+		}
+	}()
+	engine.ServeHTTP(w, req)
+	return nil
+}
+
+// cacheableStatus 是默认可以缓存的状态码。
+var cacheableStatus = map[int]bool{
+	http.StatusOK: true, http.StatusNonAuthoritativeInfo: true, http.StatusNoContent: true,
+	http.StatusMultipleChoices: true, http.StatusMovedPermanently: true,
+	http.StatusNotFound: true, http.StatusGone: true,
+}
+
+// ttl 根据响应计算有效期，不能缓存时返回原因。
+func (ch *Cache) ttl(rec *recorder) (time.Duration, string) {
+	if !cacheableStatus[rec.status] {
+		return 0, "status " + strconv.Itoa(rec.status)
+	}
+	if rec.header.Get("Set-Cookie") != "" {
+		return 0, "Set-Cookie"
+	}
+	for _, v := range rec.header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" && !ch.varies(name) {
+				return 0, "Vary: " + name
+			}
+		}
+	}
+	directives := parseCacheControl(rec.header.Get("Cache-Control"))
+	for _, d := range []string{"no-store", "no-cache", "private"} {
+		if _, ok := directives[d]; ok {
+			return 0, d
+		}
+	}
+	for _, d := range []string{"s-maxage", "max-age"} {
+		if v, ok := directives[d]; ok {
+			seconds, err := strconv.Atoi(v)
+			if err != nil || seconds <= 0 {
+				return 0, d + "=" + v
+			}
+			return time.Duration(seconds) * time.Second, ""
+		}
+	}
+	return ch.config.TTL, ""
+}
+
+// varies 判断请求头是否参与了缓存键。
+func (ch *Cache) varies(name string) bool {
+	for _, v := range ch.config.Vary {
+		if strings.EqualFold(v, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCacheControl 解析 Cache-Control 头，指令名转换为小写。
+func parseCacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, arg, _ := strings.Cut(part, "=")
+		directives[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(arg), `"`)
+	}
+	return directives
+}
+
+// recorder 记录处理函数写出的响应。
+type recorder struct {
+	header http.Header
+	status int
+	body   []byte
+}
+
+func newRecorder() *recorder {
+	return &recorder{header: make(http.Header)}
+}
+
+func (r *recorder) Header() http.Header { return r.header }
+
+func (r *recorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	r.body = append(r.body, b...)
+	return len(b), nil
+}
+
+// Flush 实现 http.Flusher，响应在加载完成后一次性写出。
+func (r *recorder) Flush() {}
+
+// writeTo 将记录的响应写给客户端。
+func (r *recorder) writeTo(c *gee.Context, status string, age time.Duration) {
+	header := c.Writer.Header()
+	for name, values := range r.header {
+		header[name] = append([]string(nil), values...)
+	}
+	header.Set("X-Cache", status)
+	if status == "HIT" {
+		header.Set("Age", strconv.FormatInt(int64(age/time.Second), 10))
+	}
+	code := r.status
+	if code == 0 {
+		code = http.StatusOK
+	}
+	c.Status(code)
+	c.Writer.Write(r.body)
+}
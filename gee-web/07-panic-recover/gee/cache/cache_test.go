@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"fmt"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"gee-web/gee-web/07-panic-recover/gee/geetest"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, config Config) (*geetest.Client, *Cache, *int) {
+	r := gee.New()
+	config.Engine = r
+	ch := New(t.Name(), 1<<20, config)
+	calls := 0
+	r.Use(ch.Middleware())
+	r.GET("/items", func(c *gee.Context) {
+		calls++
+		c.JSON(http.StatusOK, gee.H{"page": c.Query("page"), "lang": c.Req.Header.Get("Accept-Language"), "calls": calls})
+	})
+	r.GET("/private", func(c *gee.Context) {
+		calls++
+		c.SetHeader("Cache-Control", "private")
+		c.String(http.StatusOK, "%d", calls)
+	})
+	r.GET("/short", func(c *gee.Context) {
+		calls++
+		c.SetHeader("Cache-Control", "max-age=5")
+		c.String(http.StatusOK, "%d", calls)
+	})
+	return geetest.New(r), ch, &calls
+}
+
+func TestHitAndMiss(t *testing.T) {
+	client, _, calls := newTestClient(t, Config{Vary: []string{"Accept-Language"}})
+
+	first := client.GET("/items?page=1&sort=name").Do()
+	if first.Header().Get("X-Cache") != "MISS" || *calls != 1 {
+		t.Fatalf("first request: X-Cache = %q, calls = %d", first.Header().Get("X-Cache"), *calls)
+	}
+	second := client.GET("/items?sort=name&page=1").Do()
+	if second.Header().Get("X-Cache") != "HIT" || *calls != 1 {
+		t.Fatalf("second request: X-Cache = %q, calls = %d", second.Header().Get("X-Cache"), *calls)
+	}
+	if second.Body.String() != first.Body.String() || second.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("cached response = %q %q", second.Header().Get("Content-Type"), second.Body.String())
+	}
+
+	client.GET("/items?page=1&sort=name").WithHeader("Accept-Language", "zh-CN").Do()
+	if *calls != 2 {
+		t.Errorf("vary header should produce a new key, calls = %d", *calls)
+	}
+	if w := client.GET("/items?page=1&sort=name").WithHeader("Cache-Control", "no-cache").Do(); w.Header().Get("X-Cache") != "BYPASS" || *calls != 3 {
+		t.Errorf("no-cache request: X-Cache = %q, calls = %d", w.Header().Get("X-Cache"), *calls)
+	}
+	if client.GET("/items?page=1&sort=name").WithHeader("Authorization", "Bearer x").Do(); *calls != 4 {
+		t.Errorf("request with credentials should bypass the cache, calls = %d", *calls)
+	}
+	if w := client.GET("/items?page=1&sort=name").WithHeader("Cookie", "session=alice").Do(); w.Header().Get("X-Cache") != "BYPASS" || *calls != 5 {
+		t.Errorf("request with cookies should bypass the cache: X-Cache = %q, calls = %d", w.Header().Get("X-Cache"), *calls)
+	}
+}
+
+func TestVaryCookie(t *testing.T) {
+	client, _, calls := newTestClient(t, Config{Vary: []string{"Cookie"}})
+	client.GET("/items").WithHeader("Cookie", "session=alice").Do()
+	if w := client.GET("/items").WithHeader("Cookie", "session=alice").Do(); w.Header().Get("X-Cache") != "HIT" || *calls != 1 {
+		t.Errorf("same cookie: X-Cache = %q, calls = %d", w.Header().Get("X-Cache"), *calls)
+	}
+	if w := client.GET("/items").WithHeader("Cookie", "session=bob").Do(); w.Header().Get("X-Cache") != "MISS" || *calls != 2 {
+		t.Errorf("another cookie: X-Cache = %q, calls = %d", w.Header().Get("X-Cache"), *calls)
+	}
+}
+
+func TestUncacheableSharedWithWaiters(t *testing.T) {
+	r := gee.New()
+	ch := New(t.Name(), 1<<20, Config{Engine: r})
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r.Use(ch.Middleware())
+	r.GET("/report", func(c *gee.Context) {
+		if calls.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		c.SetHeader("Cache-Control", "no-store")
+		c.String(http.StatusOK, "report")
+	})
+
+	client := geetest.New(r)
+	var wg sync.WaitGroup
+	results := make([]*httptest.ResponseRecorder, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = client.GET("/report").Do()
+		}(i)
+		if i == 0 {
+			<-started
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("waiters should reuse the uncacheable response, handler called %d times", calls.Load())
+	}
+	for i, w := range results {
+		if w.Code != http.StatusOK || w.Body.String() != "report" {
+			t.Errorf("request %d: %d %q", i, w.Code, w.Body.String())
+		}
+	}
+	if client.GET("/report").Do(); calls.Load() != 2 {
+		t.Errorf("uncacheable response should not be stored, calls = %d", calls.Load())
+	}
+}
+
+func TestResponseDirectives(t *testing.T) {
+	client, ch, calls := newTestClient(t, Config{})
+	now := time.Unix(1000*60, 0)
+	ch.now = func() time.Time { return now }
+
+	client.GET("/private").Do()
+	if w := client.GET("/private").Do(); w.Body.String() != "2" {
+		t.Errorf("private response should not be cached, body = %q", w.Body.String())
+	}
+
+	client.GET("/short").Do()
+	now = now.Add(3 * time.Second)
+	if w := client.GET("/short").Do(); w.Header().Get("X-Cache") != "HIT" || w.Header().Get("Age") != "3" {
+		t.Errorf("X-Cache = %q, Age = %q", w.Header().Get("X-Cache"), w.Header().Get("Age"))
+	}
+	if w := client.GET("/short").WithHeader("Cache-Control", "max-age=1").Do(); w.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("request max-age should reject older entry, X-Cache = %q", w.Header().Get("X-Cache"))
+	}
+	now = now.Add(3 * time.Second)
+	before := *calls
+	if client.GET("/short").Do(); *calls != before+1 {
+		t.Error("expired entry should not be served")
+	}
+	// 条目在 1 分钟的时间窗口结束前过期后，仍然可以重新缓存
+	for i := 0; i < 2; i++ {
+		now = now.Add(time.Second)
+		if w := client.GET("/short").Do(); w.Header().Get("X-Cache") != "HIT" || *calls != before+1 {
+			t.Errorf("entry reloaded after max-age should be cached, X-Cache = %q, calls = %d", w.Header().Get("X-Cache"), *calls)
+		}
+	}
+}
+
+func TestReplayForPeer(t *testing.T) {
+	client, ch, calls := newTestClient(t, Config{})
+	now := time.Unix(1000*60, 0)
+	ch.now = func() time.Time { return now }
+	req := httptest.NewRequest(http.MethodGet, "/items?page=2", nil)
+	c, _ := geetest.NewContext(req)
+	key := DefaultKey(c, nil).encode(now, time.Minute)
+
+	// 没有等待中的请求时，Getter 通过 Engine 重新执行请求
+	view, err := ch.Group().Get(key)
+	if err != nil || *calls != 1 {
+		t.Fatalf("Get: %v, calls = %d", err, *calls)
+	}
+	if w := client.GET("/items?page=2").Do(); w.Header().Get("X-Cache") != "HIT" || *calls != 1 {
+		t.Errorf("X-Cache = %q, calls = %d, entry = %s", w.Header().Get("X-Cache"), *calls, view)
+	}
+}
+
+func TestKeyRoundTrip(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://Example.com/a%20b?x=1&y=a%26b", nil)
+	req.Header.Set("Accept-Language", "en")
+	c, _ := geetest.NewContext(req)
+	k, err := parseKey(DefaultKey(c, []string{"accept-language"}).encode(time.Unix(7, 0), time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := fmt.Sprint(k.Method, k.Host, k.Path, k.Query.Get("y"), k.Header.Get("Accept-Language"))
+	if got != "GETexample.com/a ba&ben" {
+		t.Errorf("parsed key = %q", got)
+	}
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"errors"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Key 描述一个可缓存的请求，响应只应该取决于 Key 中的内容。
+// Key 会被编码为 geecache 的键，远程节点可以从键还原出请求并重新执行。
+type Key struct {
+	Method string
+	Host   string
+	Path   string
+	Query  url.Values
+	Header http.Header // 只包含 Vary 中列出的请求头
+}
+
+// KeyFunc 根据请求和 Vary 请求头列表生成缓存键，可以用于忽略无关的查询参数或规范化路径。
+type KeyFunc func(c *gee.Context, vary []string) Key
+
+// DefaultKey 使用请求的方法、主机、路径、全部查询参数以及 vary 中列出的请求头生成缓存键。
+func DefaultKey(c *gee.Context, vary []string) Key {
+	k := Key{
+		Method: c.Method,
+		Host:   strings.ToLower(c.Req.Host),
+		Path:   c.Path,
+		Query:  c.Req.URL.Query(),
+		Header: make(http.Header),
+	}
+	for _, name := range vary {
+		if values := c.Req.Header.Values(name); len(values) > 0 {
+			k.Header[http.CanonicalHeaderKey(name)] = []string{strings.Join(values, ", ")}
+		}
+	}
+	return k
+}
+
+// encode 将 Key 与 now 所在的时间窗口编码为确定的字符串，窗口长度为 size，查询参数和请求头的顺序不影响结果。
+func (k Key) encode(now time.Time, size time.Duration) string {
+	v := url.Values{}
+	v.Set("m", k.Method)
+	v.Set("h", k.Host)
+	v.Set("p", k.Path)
+	v.Set("q", k.Query.Encode())
+	for name, values := range k.Header {
+		v.Set("H"+http.CanonicalHeaderKey(name), strings.Join(values, ", "))
+	}
+	v.Set("w", strconv.FormatInt(now.UnixNano()/int64(size), 10))
+	v.Set("t", strconv.FormatInt(int64(size), 10))
+	return v.Encode()
+}
+
+// windowEnd 返回键中时间窗口的结束时间，键中没有合法的窗口时返回 false。
+func windowEnd(s string) (time.Time, bool) {
+	v, err := url.ParseQuery(s)
+	if err != nil {
+		return time.Time{}, false
+	}
+	window, err1 := strconv.ParseInt(v.Get("w"), 10, 64)
+	size, err2 := strconv.ParseInt(v.Get("t"), 10, 64)
+	if err1 != nil || err2 != nil || size <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, (window+1)*size), true
+}
+
+// parseKey 是 encode 的逆操作。
+func parseKey(s string) (Key, error) {
+	v, err := url.ParseQuery(s)
+	if err != nil {
+		return Key{}, err
+	}
+	if v.Get("m") == "" || v.Get("p") == "" {
+		return Key{}, errors.New("cache: malformed key")
+	}
+	query, err := url.ParseQuery(v.Get("q"))
+	if err != nil {
+		return Key{}, err
+	}
+	k := Key{Method: v.Get("m"), Host: v.Get("h"), Path: v.Get("p"), Query: query, Header: make(http.Header)}
+	for name := range v {
+		if strings.HasPrefix(name, "H") {
+			k.Header.Set(name[1:], v.Get(name))
+		}
+	}
+	return k, nil
+}
+
+// request 根据 Key 构造一个用于重新执行的请求。
+func (k Key) request() *http.Request {
+	u := &url.URL{Path: k.Path, RawQuery: k.Query.Encode()}
+	req, _ := http.NewRequest(k.Method, u.RequestURI(), nil)
+	req.Host = k.Host
+	req.RequestURI = u.RequestURI()
+	for name, values := range k.Header {
+		req.Header[name] = values
+	}
+	return req
+}
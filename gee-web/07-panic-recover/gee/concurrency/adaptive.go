@@ -0,0 +1,79 @@
+package concurrency
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Adaptive 是自适应并发上限的配置，采用梯度算法：
+// 比较请求处理时长的短期均值和长期基线，短期延迟明显升高说明下游已经开始排队，按比例降低上限；
+// 延迟恢复后上限逐步增长，最多回到 Config.Limit。
+type Adaptive struct {
+	MinLimit int // 上限的下限，默认为 1
+	// Tolerance 是短期延迟可以超过长期基线的倍数，超过后开始降低上限，默认为 1.5。
+	Tolerance float64
+	// Smoothing 是每次调整上限时新值所占的比例，取值 (0, 1]，默认为 0.2。
+	Smoothing float64
+}
+
+// gradient 根据请求处理时长计算并发上限。
+type gradient struct {
+	min, max  float64
+	tolerance float64
+	smoothing float64
+
+	mu    sync.Mutex
+	limit float64
+	short float64 // 短期延迟的指数移动平均，单位纳秒
+	long  float64 // 长期延迟的指数移动平均，作为基线
+}
+
+func newGradient(a Adaptive, max int) *gradient {
+	if a.MinLimit <= 0 {
+		a.MinLimit = 1
+	}
+	if a.MinLimit > max {
+		a.MinLimit = max
+	}
+	if a.Tolerance < 1 {
+		a.Tolerance = 1.5
+	}
+	if a.Smoothing <= 0 || a.Smoothing > 1 {
+		a.Smoothing = 0.2
+	}
+	return &gradient{
+		min:       float64(a.MinLimit),
+		max:       float64(max),
+		tolerance: a.Tolerance,
+		smoothing: a.Smoothing,
+		limit:     float64(max),
+	}
+}
+
+// update 记录一次请求的处理时长并返回新的上限。
+// inflight 是该请求完成前正在处理的请求数，负载远低于上限时延迟不能说明上限是否合适，不增大上限。
+func (g *gradient) update(rtt time.Duration, inflight int) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	sample := float64(rtt)
+	if g.long == 0 {
+		g.short, g.long = sample, sample
+	}
+	g.short += (sample - g.short) * 0.1
+	g.long += (sample - g.long) * 0.01
+	// 持续过载时基线会被逐渐抬高，短期延迟回落到基线以下说明过载已经结束，让基线随之下降
+	if g.long > g.short*2 {
+		g.long *= 0.95
+	}
+
+	ratio := math.Max(0.5, math.Min(1, g.tolerance*g.long/g.short))
+	if ratio == 1 && float64(inflight) < g.limit/2 {
+		return int(g.limit)
+	}
+	// 加上 sqrt(limit) 作为增长空间，延迟正常时上限逐步增大
+	next := g.limit*ratio + math.Sqrt(g.limit)
+	next = g.limit*(1-g.smoothing) + next*g.smoothing
+	g.limit = math.Max(g.min, math.Min(g.max, next))
+	return int(g.limit)
+}
@@ -0,0 +1,127 @@
+package concurrency
+
+import (
+	"gee-web/gee-web/07-panic-recover/gee"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Config 是并发限制中间件的配置。
+type Config struct {
+	// Limit 是全局同时处理的请求数上限，为 0 时不限制全局并发。
+	Limit int
+	// RouteLimit 是每个路由（方法和路由模式，例如 "GET /users/:id"）同时处理的请求数上限，为 0 时不限制。
+	RouteLimit int
+	// QueueSize 是超过上限的请求可以排队等待的数量，全局和每个路由各有一个队列。为 0 时超过上限立即拒绝。
+	QueueSize int
+	// QueueTimeout 是请求排队等待的最长时间，默认为 1 秒。
+	QueueTimeout time.Duration
+	// Adaptive 不为 nil 时根据处理时长自适应地调整全局上限，Limit 是上限的最大值，此时 Limit 必须大于 0。
+	Adaptive *Adaptive
+	// RetryAfter 是被拒绝的请求返回的 Retry-After，默认为 1 秒。
+	RetryAfter time.Duration
+}
+
+// Limiter 限制同时处理的请求数。
+type Limiter struct {
+	config   Config
+	global   *limiter
+	routes   sync.Map // "METHOD /pattern" -> *limiter
+	gradient *gradient
+}
+
+// Stats 是限制器的当前状态。
+type Stats struct {
+	Limit    int // 当前的全局上限，自适应模式下会随延迟变化
+	InFlight int // 正在处理的请求数
+	Queued   int // 排队等待的请求数
+}
+
+// New 创建并发限制器，通过 Middleware 挂载到 Engine 或 RouterGroup 上。
+func New(config Config) *Limiter {
+	if config.Limit < 0 || config.RouteLimit < 0 || config.QueueSize < 0 {
+		panic("concurrency: negative limit or queue size")
+	}
+	if config.Adaptive != nil && config.Limit == 0 {
+		panic("concurrency: Adaptive requires Limit")
+	}
+	if config.QueueTimeout <= 0 {
+		config.QueueTimeout = time.Second
+	}
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = time.Second
+	}
+	l := &Limiter{config: config}
+	if config.Limit > 0 {
+		l.global = newLimiter(config.Limit, config.QueueSize)
+	}
+	if config.Adaptive != nil {
+		l.gradient = newGradient(*config.Adaptive, config.Limit)
+	}
+	return l
+}
+
+// Stats 返回全局限制的当前状态，没有设置 Limit 时返回零值。
+func (l *Limiter) Stats() Stats {
+	if l.global == nil {
+		return Stats{}
+	}
+	limit, inflight, queued := l.global.stats()
+	return Stats{Limit: limit, InFlight: inflight, Queued: queued}
+}
+
+// Middleware 返回并发限制中间件。
+// 超过上限的请求在队列中等待，队列已满或等待超时的请求返回 503 并带上 Retry-After 头。
+// 每个路由的限制按 FullPath 区分，没有匹配到路由的请求只受全局限制。
+func (l *Limiter) Middleware() gee.HandlerFunc {
+	return func(c *gee.Context) {
+		deadline := time.Now().Add(l.config.QueueTimeout)
+		ctx := c.Req.Context()
+		// 先获取路由的名额再获取全局名额，避免慢路由排队时占用全局名额
+		if route := l.route(c); route != nil {
+			if !route.acquire(ctx, deadline) {
+				l.reject(c)
+				return
+			}
+			defer route.release()
+		}
+		if l.global == nil {
+			c.Next()
+			return
+		}
+		if !l.global.acquire(ctx, deadline) {
+			l.reject(c)
+			return
+		}
+		defer l.global.release()
+
+		start := time.Now()
+		c.Next()
+		if l.gradient != nil {
+			_, inflight, _ := l.global.stats()
+			l.global.setLimit(l.gradient.update(time.Since(start), inflight))
+		}
+	}
+}
+
+// route 返回当前路由的限制器，没有设置 RouteLimit 或没有匹配到路由时返回 nil。
+func (l *Limiter) route(c *gee.Context) *limiter {
+	if l.config.RouteLimit == 0 || c.FullPath() == "" {
+		return nil
+	}
+	key := c.Method + " " + c.FullPath()
+	if v, ok := l.routes.Load(key); ok {
+		return v.(*limiter)
+	}
+	v, _ := l.routes.LoadOrStore(key, newLimiter(l.config.RouteLimit, l.config.QueueSize))
+	return v.(*limiter)
+}
+
+// reject 返回 503 并带上 Retry-After 头。
+func (l *Limiter) reject(c *gee.Context) {
+	retry := int64((l.config.RetryAfter + time.Second - 1) / time.Second)
+	c.Writer.Header().Set("Retry-After", strconv.FormatInt(retry, 10))
+	c.Fail(http.StatusServiceUnavailable, http.StatusText(http.StatusServiceUnavailable))
+}
@@ -0,0 +1,97 @@
+package concurrency
+
+import (
+	"context"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"gee-web/gee-web/07-panic-recover/gee/geetest"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimiterQueue(t *testing.T) {
+	l := newLimiter(1, 1)
+	ctx := context.Background()
+	if !l.acquire(ctx, time.Now()) {
+		t.Fatal("first acquire should succeed")
+	}
+
+	acquired := make(chan bool)
+	go func() { acquired <- l.acquire(ctx, time.Now().Add(time.Second)) }()
+	for _, _, queued := l.stats(); queued != 1; _, _, queued = l.stats() {
+		time.Sleep(time.Millisecond)
+	}
+	if l.acquire(ctx, time.Now().Add(time.Second)) {
+		t.Fatal("acquire should fail when the queue is full")
+	}
+	l.release()
+	if !<-acquired {
+		t.Fatal("queued acquire should succeed after release")
+	}
+	if l.acquire(ctx, time.Now().Add(10*time.Millisecond)) {
+		t.Fatal("queued acquire should time out")
+	}
+	l.setLimit(2)
+	if !l.acquire(ctx, time.Now()) {
+		t.Fatal("acquire should succeed after the limit is raised")
+	}
+	if limit, inflight, queued := l.stats(); limit != 2 || inflight != 2 || queued != 0 {
+		t.Fatalf("stats = %d, %d, %d", limit, inflight, queued)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	l := New(Config{Limit: 10, RouteLimit: 1, RetryAfter: 1500 * time.Millisecond})
+	release := make(chan struct{})
+	started := make(chan struct{})
+	r := gee.New()
+	r.Use(l.Middleware())
+	r.GET("/slow", func(c *gee.Context) {
+		close(started)
+		<-release
+		c.String(http.StatusOK, "slow")
+	})
+	r.GET("/fast", func(c *gee.Context) { c.String(http.StatusOK, "fast") })
+
+	client := geetest.New(r)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		client.GET("/slow").Do()
+	}()
+	<-started
+	if stats := l.Stats(); stats.InFlight != 1 {
+		t.Errorf("InFlight = %d, want 1", stats.InFlight)
+	}
+
+	client.GET("/slow").Expect(t).Status(http.StatusServiceUnavailable).Header("Retry-After", "2")
+	// 其他路由不受影响
+	client.GET("/fast").Expect(t).Status(http.StatusOK)
+	close(release)
+	wg.Wait()
+}
+
+func TestGradient(t *testing.T) {
+	g := newGradient(Adaptive{MinLimit: 2}, 100)
+	limit := 0
+	for i := 0; i < 100; i++ {
+		limit = g.update(10*time.Millisecond, 100)
+	}
+	if limit != 100 {
+		t.Fatalf("limit should stay at the maximum under stable latency, got %d", limit)
+	}
+	for i := 0; i < 50; i++ {
+		limit = g.update(100*time.Millisecond, limit)
+	}
+	if limit > 10 {
+		t.Fatalf("limit should shrink when latency rises, got %d", limit)
+	}
+	for i := 0; i < 200; i++ {
+		limit = g.update(10*time.Millisecond, limit)
+	}
+	if limit != 100 {
+		t.Fatalf("limit should recover when latency drops, got %d", limit)
+	}
+}
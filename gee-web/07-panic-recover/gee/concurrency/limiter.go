@@ -0,0 +1,91 @@
+package concurrency
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// limiter 是上限可以动态调整的信号量，超过上限的请求按先来先服务的顺序在有界队列中等待。
+type limiter struct {
+	mu       sync.Mutex
+	limit    int
+	inflight int
+	queue    int       // 队列长度上限
+	waiters  list.List // 等待中的 chan struct{}
+}
+
+func newLimiter(limit, queue int) *limiter {
+	return &limiter{limit: limit, queue: queue}
+}
+
+// acquire 获取一个名额，队列已满、等待超过 deadline 或 ctx 被取消时返回 false。
+func (l *limiter) acquire(ctx context.Context, deadline time.Time) bool {
+	l.mu.Lock()
+	if l.inflight < l.limit && l.waiters.Len() == 0 {
+		l.inflight++
+		l.mu.Unlock()
+		return true
+	}
+	if l.waiters.Len() >= l.queue {
+		l.mu.Unlock()
+		return false
+	}
+	ready := make(chan struct{})
+	elem := l.waiters.PushBack(ready)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-ready:
+		return true
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-ready:
+		// 超时的同时被 release 分配了名额，归还给下一个等待者
+		l.inflight--
+		l.grant()
+	default:
+		l.waiters.Remove(elem)
+	}
+	return false
+}
+
+// release 归还一个名额。
+func (l *limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inflight--
+	l.grant()
+}
+
+// setLimit 调整上限，上限变大时唤醒等待者；变小时已经在处理的请求不受影响。
+func (l *limiter) setLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	l.grant()
+}
+
+// grant 在还有名额时按顺序唤醒等待者，调用方需要持有 mu。
+func (l *limiter) grant() {
+	for l.inflight < l.limit && l.waiters.Len() > 0 {
+		ready := l.waiters.Remove(l.waiters.Front()).(chan struct{})
+		l.inflight++
+		close(ready)
+	}
+}
+
+// stats 返回当前的上限、处理中和排队中的请求数。
+func (l *limiter) stats() (limit, inflight, queued int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit, l.inflight, l.waiters.Len()
+}
@@ -1,11 +1,29 @@
 package gee
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 )
 
+// defaultMultipartMemory 是 Engine.MaxMultipartMemory 的默认值。
+const defaultMultipartMemory = 32 << 20 // 32 MB
+
 // H 是一个类型，表示键值对集合，用于存储动态数据。
 type H map[string]interface{}
 
@@ -18,6 +36,8 @@ type Context struct {
 	Path   string // 请求路径
 	Method string // 请求方法
 	Params map[string]string
+	// 匹配到的路由模式，例如 "/hello/:name"，未匹配时为空
+	fullPath string
 	// 响应信息
 	StatusCode int // HTTP 响应状态码
 	// middleware
@@ -25,6 +45,11 @@ type Context struct {
 	index    int
 	// engine pointer
 	engine *Engine // 存储引擎的指针
+	// 请求级别的键值存储，供中间件之间传递数据
+	mu   sync.RWMutex
+	Keys map[string]interface{}
+	// SetCookie 使用的 SameSite 属性
+	sameSite http.SameSite
 }
 
 // newContext 创建并返回一个新的 Context 实例。
@@ -63,12 +88,79 @@ func (c *Context) Next() {
 //	code - HTTP状态码，表示错误的类型。
 //	err  - 错误消息，提供具体的错误信息。
 func (c *Context) Fail(code int, err string) {
-	// 将索引设置为处理函数列表的末尾，以中断后续的处理。
-	c.index = len(c.handlers)
-	// 使用JSON格式返回错误信息和状态码。
-	c.JSON(code, H{"message": err})
+	c.AbortWithStatusJSON(code, H{"message": err})
+}
+
+// abortIndex 是中断后的索引，远大于处理函数的数量，因此可以与正常执行完毕区分。
+const abortIndex = math.MaxInt32
+
+// Abort 中断处理链，当前处理函数返回后不再执行后续的处理函数，已经执行的中间件中 c.Next() 之后的代码仍会执行。
+// Abort 不会写入响应，需要时使用 AbortWithStatus 或 AbortWithStatusJSON。
+func (c *Context) Abort() {
+	c.index = abortIndex
+}
+
+// IsAborted 返回处理链是否已被中断，中间件可以在 c.Next() 之后用它判断后续的处理函数是否中断了请求。
+func (c *Context) IsAborted() bool {
+	return c.index >= abortIndex
+}
+
+// AbortWithStatus 写入状态码并中断处理链。
+// 参数:
+// - code: int，HTTP 状态码。
+func (c *Context) AbortWithStatus(code int) {
+	c.Abort()
+	c.Status(code)
+}
+
+// AbortWithStatusJSON 中断处理链并返回 JSON 响应。
+// 参数:
+// - code: int，HTTP 状态码。
+// - obj: interface{}，要序列化的对象。
+func (c *Context) AbortWithStatusJSON(code int, obj interface{}) {
+	c.Abort()
+	c.JSON(code, obj)
 }
 
+// Copy 返回当前 Context 的只读快照，可以在处理函数返回后使用，例如传给后台 goroutine。
+// 快照复制了 Params 和 Keys，请求的 context 不会随请求结束而取消，但保留其中的值；
+// 快照不能写响应，Writer 的 Write 总是返回 ErrCopiedContext，也不能调用 Next 继续处理链。
+func (c *Context) Copy() *Context {
+	cp := &Context{
+		Writer:     &copiedWriter{header: make(http.Header)},
+		Req:        c.Req.WithContext(context.WithoutCancel(c.Req.Context())),
+		Path:       c.Path,
+		Method:     c.Method,
+		fullPath:   c.fullPath,
+		StatusCode: c.StatusCode,
+		index:      abortIndex,
+		engine:     c.engine,
+		Keys:       c.copyKeys(),
+		sameSite:   c.sameSite,
+	}
+	if c.Params != nil {
+		cp.Params = make(map[string]string, len(c.Params))
+		for k, v := range c.Params {
+			cp.Params[k] = v
+		}
+	}
+	return cp
+}
+
+// ErrCopiedContext 是通过 Copy 得到的 Context 写响应时返回的错误。
+var ErrCopiedContext = errors.New("gee: cannot write response from a copied Context")
+
+// copiedWriter 是 Copy 返回的 Context 使用的 Writer，丢弃所有写入。
+type copiedWriter struct {
+	header http.Header
+}
+
+func (w *copiedWriter) Header() http.Header { return w.header }
+
+func (w *copiedWriter) Write([]byte) (int, error) { return 0, ErrCopiedContext }
+
+func (w *copiedWriter) WriteHeader(int) {}
+
 // Param 从 Context 的 Params 映射中获取指定 key 对应的值。
 // 如果 key 存在，则返回对应的值；如果 key 不存在，则返回空字符串。
 // 参数:
@@ -83,6 +175,53 @@ func (c *Context) Param(key string) string {
 	return value
 }
 
+// Set 在当前请求的上下文中保存一个键值对，通常用于在中间件之间传递数据。
+// 参数:
+// - key: string，键。
+// - value: interface{}，值。
+func (c *Context) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Keys == nil {
+		c.Keys = make(map[string]interface{})
+	}
+	c.Keys[key] = value
+}
+
+// Get 返回通过 Set 保存的值，exists 表示该键是否存在。
+// 参数:
+// - key: string，键。
+// 返回值:
+// - value: interface{}，对应的值。
+// - exists: bool，键是否存在。
+func (c *Context) Get(key string) (value interface{}, exists bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	value, exists = c.Keys[key]
+	return
+}
+
+// MustGet 返回通过 Set 保存的值，如果键不存在则触发 panic。
+func (c *Context) MustGet(key string) interface{} {
+	if value, exists := c.Get(key); exists {
+		return value
+	}
+	panic("Key \"" + key + "\" does not exist")
+}
+
+// GetString 以字符串形式返回通过 Set 保存的值，类型不匹配时返回空字符串。
+func (c *Context) GetString(key string) (s string) {
+	if value, ok := c.Get(key); ok && value != nil {
+		s, _ = value.(string)
+	}
+	return
+}
+
+// FullPath 返回当前请求匹配到的路由模式，例如 "/hello/:name"；没有匹配到路由时返回空字符串。
+func (c *Context) FullPath() string {
+	return c.fullPath
+}
+
 // PostForm 从 POST 表单数据中获取指定 key 的值。
 // 参数:
 // - key: string，表单字段的键。
@@ -92,6 +231,67 @@ func (c *Context) PostForm(key string) string {
 	return c.Req.FormValue(key)
 }
 
+// MultipartForm 解析并返回 multipart 表单，包括上传的文件。
+// 超过 Engine.MaxMultipartMemory 的文件内容会写入临时文件，而不是全部保存在内存中。
+func (c *Context) MultipartForm() (*multipart.Form, error) {
+	maxMemory := int64(defaultMultipartMemory)
+	if c.engine != nil {
+		maxMemory = c.engine.MaxMultipartMemory
+	}
+	if err := c.Req.ParseMultipartForm(maxMemory); err != nil {
+		return nil, err
+	}
+	return c.Req.MultipartForm, nil
+}
+
+// FormFile 返回 multipart 表单中 name 字段上传的第一个文件。
+// 参数:
+// - name: string，表单字段名。
+// 返回值:
+// - *multipart.FileHeader: 文件信息，可以通过 Open 读取内容。
+// - error: 解析失败或字段不存在时的错误。
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	if _, err := c.MultipartForm(); err != nil {
+		return nil, err
+	}
+	f, fh, err := c.Req.FormFile(name)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	return fh, nil
+}
+
+// SaveUploadedFile 将上传的文件保存到 dst，dst 所在的目录不存在时会自动创建。
+// 参数:
+// - fh: *multipart.FileHeader，上传的文件。
+// - dst: string，目标文件路径。
+func (c *Context) SaveUploadedFile(fh *multipart.FileHeader, dst string) error {
+	src, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err = os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, src)
+	return err
+}
+
+// MultipartReader 返回 multipart 请求体的流式读取器，用于逐个处理上传的文件而不缓存整个请求体。
+// 使用后不能再调用 MultipartForm、FormFile 或 PostForm。
+func (c *Context) MultipartReader() (*multipart.Reader, error) {
+	return c.Req.MultipartReader()
+}
+
 // Query 从 URL 查询参数中获取指定 key 的值。
 // 参数:
 // - key: string，查询参数的键。
@@ -101,6 +301,53 @@ func (c *Context) Query(key string) string {
 	return c.Req.URL.Query().Get(key)
 }
 
+// SetSameSite 设置之后调用 SetCookie 时使用的 SameSite 属性。
+// 参数:
+// - sameSite: http.SameSite，例如 http.SameSiteLaxMode。
+func (c *Context) SetSameSite(sameSite http.SameSite) {
+	c.sameSite = sameSite
+}
+
+// SetCookie 向响应中添加一个 Set-Cookie 头，value 会进行 URL 编码。
+// 参数:
+// - name: string，Cookie 名称。
+// - value: string，Cookie 值。
+// - maxAge: int，有效期（秒），小于 0 表示立即删除，0 表示会话 Cookie。
+// - path: string，Cookie 路径，为空时使用 "/"。
+// - domain: string，Cookie 所属域名。
+// - secure: bool，是否仅通过 HTTPS 发送。
+// - httpOnly: bool，是否禁止脚本访问。
+func (c *Context) SetCookie(name, value string, maxAge int, path, domain string, secure, httpOnly bool) {
+	if path == "" {
+		path = "/"
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    url.QueryEscape(value),
+		MaxAge:   maxAge,
+		Path:     path,
+		Domain:   domain,
+		SameSite: c.sameSite,
+		Secure:   secure,
+		HttpOnly: httpOnly,
+	})
+}
+
+// Cookie 返回请求中指定名称的 Cookie 值（已进行 URL 解码）。
+// 如果 Cookie 不存在，返回 http.ErrNoCookie。
+// 参数:
+// - name: string，Cookie 名称。
+// 返回值:
+// - string: Cookie 值。
+// - error: 查找或解码失败时的错误。
+func (c *Context) Cookie(name string) (string, error) {
+	cookie, err := c.Req.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return url.QueryUnescape(cookie.Value)
+}
+
 // Status 设置 HTTP 响应的状态码。
 // 参数:
 // - code: int，HTTP 状态码。
@@ -141,6 +388,19 @@ func (c *Context) JSON(code int, obj interface{}) {
 	}
 }
 
+// XML 返回一个带有指定状态码和 XML 数据的响应。
+// 参数:
+// - code: int，HTTP 状态码。
+// - obj: interface{}，要序列化的对象。
+func (c *Context) XML(code int, obj interface{}) {
+	c.SetHeader("Content-Type", "application/xml")
+	c.Status(code)
+	encoder := xml.NewEncoder(c.Writer)
+	if err := encoder.Encode(obj); err != nil {
+		http.Error(c.Writer, err.Error(), 500)
+	}
+}
+
 // Data 返回一个带有指定状态码和字节数组数据的响应。
 // 参数:
 // - code: int，HTTP 状态码。
@@ -159,3 +419,158 @@ func (c *Context) HTML(code int, html string) {
 	c.Status(code)
 	c.Writer.Write([]byte(html))
 }
+
+// HTMLTemplate 使用 Engine.LoadHTMLGlob 加载的模板渲染 HTML 响应。
+// 参数:
+// - code: int，HTTP 状态码。
+// - name: string，模板名称。
+// - data: interface{}，传递给模板的数据。
+func (c *Context) HTMLTemplate(code int, name string, data interface{}) {
+	if c.engine == nil {
+		c.Fail(http.StatusInternalServerError, "gee: HTMLTemplate requires an Engine")
+		return
+	}
+	tmpl, err := c.engine.templates()
+	if err != nil {
+		c.Fail(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if tmpl == nil {
+		c.Fail(http.StatusInternalServerError, "gee: no HTML templates loaded, call LoadHTMLGlob first")
+		return
+	}
+	// 先渲染到缓冲区，渲染失败时还可以返回 500
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		c.Fail(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.SetHeader("Content-Type", "text/html")
+	c.Status(code)
+	c.Writer.Write(buf.Bytes())
+}
+
+// Redirect 返回重定向响应，code 必须是 3xx 重定向状态码或 201，否则触发 panic。
+// 参数:
+// - code: int，HTTP 状态码，例如 http.StatusFound。
+// - location: string，重定向的目标地址，可以是相对路径。
+func (c *Context) Redirect(code int, location string) {
+	if (code < http.StatusMultipleChoices || code > http.StatusPermanentRedirect ||
+		code == http.StatusNotModified || code == http.StatusUseProxy || code == 306) && code != http.StatusCreated {
+		panic(fmt.Sprintf("Cannot redirect with status code %d", code))
+	}
+	sw := &statusWriter{ResponseWriter: c.Writer}
+	http.Redirect(sw, c.Req, location, code)
+	c.StatusCode = sw.status
+}
+
+// File 返回本地文件的内容，支持 Range 和条件请求（If-Modified-Since、If-None-Match 等）。
+// 文件不存在或是目录时返回 404。
+// 参数:
+// - filePath: string，本地文件路径。
+func (c *Context) File(filePath string) {
+	f, err := os.Open(filePath)
+	c.sendFile(f, err, filepath.Base(filePath))
+}
+
+// FileFromFS 返回 fsys 中名为 name 的文件内容，支持 Range 和条件请求。
+// 参数:
+// - name: string，文件名，会去掉 "." 和 ".." 等元素。
+// - fsys: fs.FS，文件系统，例如 embed.FS 或 os.DirFS。
+func (c *Context) FileFromFS(name string, fsys fs.FS) {
+	name = cleanName(name)
+	f, err := fsys.Open(name)
+	c.sendFile(f, err, name)
+}
+
+// FileAttachment 以附件形式返回本地文件，浏览器会以 filename 作为文件名下载。
+// 非 ASCII 文件名按 RFC 6266 同时给出 filename 和 RFC 5987 编码的 filename*。
+// 参数:
+// - filePath: string，本地文件路径。
+// - filename: string，下载时使用的文件名。
+func (c *Context) FileAttachment(filePath, filename string) {
+	c.SetHeader("Content-Disposition", contentDisposition("attachment", filename))
+	c.File(filePath)
+}
+
+// DataFromReader 将 reader 的内容写入响应。
+// 参数:
+// - code: int，HTTP 状态码。
+// - contentLength: int64，内容长度，小于 0 时不设置 Content-Length。
+// - contentType: string，内容类型。
+// - reader: io.Reader，响应内容。
+// - extraHeaders: map[string]string，额外的响应头，例如 Content-Disposition。
+func (c *Context) DataFromReader(code int, contentLength int64, contentType string, reader io.Reader, extraHeaders map[string]string) {
+	for key, value := range extraHeaders {
+		c.SetHeader(key, value)
+	}
+	if contentType != "" {
+		c.SetHeader("Content-Type", contentType)
+	}
+	if contentLength >= 0 {
+		c.SetHeader("Content-Length", strconv.FormatInt(contentLength, 10))
+	}
+	c.Status(code)
+	io.Copy(c.Writer, reader)
+}
+
+// sendFile 是 File 和 FileFromFS 的公共部分，err 是打开文件时的错误。
+func (c *Context) sendFile(f fs.File, err error, name string) {
+	if err != nil {
+		switch {
+		case errors.Is(err, fs.ErrPermission):
+			c.String(http.StatusForbidden, "403 Forbidden\n")
+		default:
+			c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.Path)
+		}
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.Path)
+		return
+	}
+	serveContent(c, path.Base(name), f, info, "")
+}
+
+// contentDisposition 生成 Content-Disposition 头。
+// filename 只包含可打印 ASCII 字符时直接使用带引号的字符串，
+// 否则 filename 使用 "_" 替换非 ASCII 字符作为兼容旧浏览器的回退，并附加 UTF-8 编码的 filename*。
+func contentDisposition(disposition, filename string) string {
+	var fallback strings.Builder
+	ascii := true
+	for _, r := range filename {
+		switch {
+		case r == '"' || r == '\\':
+			fallback.WriteByte('\\')
+			fallback.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fallback.WriteByte('_')
+		case r > 0x7f:
+			ascii = false
+			fallback.WriteByte('_')
+		default:
+			fallback.WriteRune(r)
+		}
+	}
+	value := disposition + `; filename="` + fallback.String() + `"`
+	if ascii {
+		return value
+	}
+	var encoded strings.Builder
+	for _, b := range []byte(filename) {
+		if isAttrChar(b) {
+			encoded.WriteByte(b)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return value + "; filename*=UTF-8''" + encoded.String()
+}
+
+// isAttrChar 判断字节是否是 RFC 5987 中不需要编码的 attr-char。
+func isAttrChar(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' ||
+		strings.IndexByte("!#$&+-.^_`|~", b) >= 0
+}
@@ -0,0 +1,99 @@
+package gee
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextKeys(t *testing.T) {
+	c := newContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	c.Set("user", "geektutu")
+	if c.GetString("user") != "geektutu" {
+		t.Fatal("user should be geektutu")
+	}
+	if _, ok := c.Get("unknown"); ok {
+		t.Fatal("unknown key should not exist")
+	}
+}
+
+func TestCookie(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := newContext(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie("name", "gee web", 3600, "", "", true, true)
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expect 1 cookie, got %d", len(cookies))
+	}
+	cookie := cookies[0]
+	if cookie.Path != "/" || !cookie.Secure || !cookie.HttpOnly || cookie.SameSite != http.SameSiteStrictMode {
+		t.Fatalf("unexpected cookie attributes: %v", cookie)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	c = newContext(httptest.NewRecorder(), req)
+	if v, err := c.Cookie("name"); err != nil || v != "gee web" {
+		t.Fatalf("expect 'gee web', got %q, %v", v, err)
+	}
+	if _, err := c.Cookie("missing"); err != http.ErrNoCookie {
+		t.Fatalf("expect ErrNoCookie, got %v", err)
+	}
+}
+
+func TestAbort(t *testing.T) {
+	var trace []string
+	var abortedAfterNext bool
+	w := httptest.NewRecorder()
+	c := newContext(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	c.handlers = []HandlerFunc{
+		func(c *Context) {
+			c.Next()
+			abortedAfterNext = c.IsAborted()
+		},
+		func(c *Context) {
+			trace = append(trace, "auth")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, H{"message": "denied"})
+		},
+		func(c *Context) { trace = append(trace, "handler") },
+	}
+	c.Next()
+	if len(trace) != 1 || !abortedAfterNext || w.Code != http.StatusUnauthorized {
+		t.Fatalf("trace = %v, aborted = %v, code = %d", trace, abortedAfterNext, w.Code)
+	}
+
+	c = newContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	c.handlers = []HandlerFunc{func(c *Context) {}}
+	c.Next()
+	if c.IsAborted() {
+		t.Fatal("completed chain should not be aborted")
+	}
+}
+
+type traceKey struct{}
+
+func TestCopy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), traceKey{}, "t1"))
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil).WithContext(ctx)
+	c := newContext(httptest.NewRecorder(), req)
+	c.Params = map[string]string{"id": "1"}
+	c.Set("user", "geektutu")
+
+	cp := c.Copy()
+	cancel()
+	c.Params["id"] = "2"
+	c.Set("user", "other")
+
+	if cp.Param("id") != "1" || cp.GetString("user") != "geektutu" {
+		t.Errorf("copy shares state with the original: id = %q, user = %q", cp.Param("id"), cp.GetString("user"))
+	}
+	if cp.Req.Context().Err() != nil || cp.Req.Context().Value(traceKey{}) != "t1" {
+		t.Error("copied request context should keep values but not be canceled")
+	}
+	if _, err := cp.Writer.Write([]byte("x")); err != ErrCopiedContext || !cp.IsAborted() {
+		t.Errorf("copied Context should not write responses, err = %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package gee
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig 是跨域资源共享（CORS）中间件的配置。
+type CORSConfig struct {
+	// AllowOrigins 是允许的来源列表，支持精确匹配、"*" 以及形如 "https://*.example.com" 的通配符。
+	AllowOrigins []string
+	// AllowOriginFunc 自定义来源校验，与 AllowOrigins 任一匹配即允许。
+	AllowOriginFunc func(origin string) bool
+	// AllowMethods 是预检请求允许的方法，默认为 GET、POST、PUT、PATCH、DELETE、HEAD。
+	AllowMethods []string
+	// AllowHeaders 是预检请求允许的请求头，为空时回显 Access-Control-Request-Headers。
+	AllowHeaders []string
+	// ExposeHeaders 是允许浏览器脚本读取的响应头。
+	ExposeHeaders []string
+	// AllowCredentials 表示是否允许携带 Cookie 等凭据。
+	AllowCredentials bool
+	// MaxAge 是预检结果的缓存时间。
+	MaxAge time.Duration
+}
+
+// cors 保存预处理后的 CORS 配置。
+type cors struct {
+	allowAll         bool
+	origins          map[string]bool
+	wildcards        [][2]string // 通配符来源的前缀和后缀
+	allowOriginFunc  func(origin string) bool
+	allowMethods     string
+	allowHeaders     string
+	exposeHeaders    string
+	allowCredentials bool
+	maxAge           string
+}
+
+// CORS 返回跨域资源共享中间件，可以通过 Use 挂载到 Engine 或任意 RouterGroup 上。
+// 预检请求（带有 Access-Control-Request-Method 的 OPTIONS 请求）会直接以 204 响应，
+// 即使没有注册对应的 OPTIONS 路由。
+func CORS(config CORSConfig) HandlerFunc {
+	methods := config.AllowMethods
+	if len(methods) == 0 {
+		methods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD"}
+	}
+	cs := &cors{
+		origins:          make(map[string]bool),
+		allowOriginFunc:  config.AllowOriginFunc,
+		allowMethods:     strings.Join(methods, ", "),
+		allowHeaders:     strings.Join(config.AllowHeaders, ", "),
+		exposeHeaders:    strings.Join(config.ExposeHeaders, ", "),
+		allowCredentials: config.AllowCredentials,
+	}
+	if config.MaxAge > 0 {
+		cs.maxAge = strconv.FormatInt(int64(config.MaxAge/time.Second), 10)
+	}
+	for _, origin := range config.AllowOrigins {
+		origin = strings.ToLower(origin)
+		if origin == "*" {
+			cs.allowAll = true
+		} else if i := strings.IndexByte(origin, '*'); i >= 0 {
+			cs.wildcards = append(cs.wildcards, [2]string{origin[:i], origin[i+1:]})
+		} else {
+			cs.origins[origin] = true
+		}
+	}
+	if cs.allowAll && cs.allowCredentials {
+		debugPrintWarning("CORS allows all origins with credentials, any site can make authenticated requests.")
+	}
+	return cs.handle
+}
+
+// handle 处理 CORS 请求。
+func (cs *cors) handle(c *Context) {
+	origin := c.Req.Header.Get("Origin")
+	if origin == "" {
+		return
+	}
+	header := c.Writer.Header()
+	preflight := c.Method == http.MethodOptions && c.Req.Header.Get("Access-Control-Request-Method") != ""
+	if !cs.allowAll || cs.allowCredentials {
+		header.Add("Vary", "Origin")
+	}
+	if preflight {
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+	}
+
+	if !cs.isOriginAllowed(origin) {
+		if preflight {
+			c.AbortWithStatus(http.StatusForbidden)
+		}
+		return
+	}
+	if cs.allowAll && !cs.allowCredentials {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if cs.allowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if !preflight {
+		if cs.exposeHeaders != "" {
+			header.Set("Access-Control-Expose-Headers", cs.exposeHeaders)
+		}
+		return
+	}
+	header.Set("Access-Control-Allow-Methods", cs.allowMethods)
+	if cs.allowHeaders != "" {
+		header.Set("Access-Control-Allow-Headers", cs.allowHeaders)
+	} else if requested := c.Req.Header.Get("Access-Control-Request-Headers"); requested != "" {
+		header.Set("Access-Control-Allow-Headers", requested)
+	}
+	if cs.maxAge != "" {
+		header.Set("Access-Control-Max-Age", cs.maxAge)
+	}
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// isOriginAllowed 判断来源是否被允许。
+func (cs *cors) isOriginAllowed(origin string) bool {
+	if cs.allowAll {
+		return true
+	}
+	lower := strings.ToLower(origin)
+	if cs.origins[lower] {
+		return true
+	}
+	for _, w := range cs.wildcards {
+		if len(lower) > len(w[0])+len(w[1]) && strings.HasPrefix(lower, w[0]) && strings.HasSuffix(lower, w[1]) {
+			return true
+		}
+	}
+	return cs.allowOriginFunc != nil && cs.allowOriginFunc(origin)
+}
@@ -0,0 +1,77 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newCORSEngine() *Engine {
+	r := New()
+	api := r.Group("/api")
+	api.Use(CORS(CORSConfig{
+		AllowOrigins:     []string{"https://example.com", "https://*.example.org"},
+		AllowOriginFunc:  func(origin string) bool { return origin == "http://localhost:8080" },
+		ExposeHeaders:    []string{"X-Total"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	}))
+	api.GET("/users", func(c *Context) {
+		c.String(http.StatusOK, "users")
+	})
+	return r
+}
+
+func TestCORSPreflight(t *testing.T) {
+	r := newCORSEngine()
+	req := httptest.NewRequest(http.MethodOptions, "/api/users", nil)
+	req.Header.Set("Origin", "https://app.example.org")
+	req.Header.Set("Access-Control-Request-Method", "PUT")
+	req.Header.Set("Access-Control-Request-Headers", "X-Token")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Fatalf("preflight should return empty 204, got %d %q", w.Code, w.Body.String())
+	}
+	h := w.Header()
+	if h.Get("Access-Control-Allow-Origin") != "https://app.example.org" ||
+		h.Get("Access-Control-Allow-Credentials") != "true" ||
+		h.Get("Access-Control-Allow-Headers") != "X-Token" ||
+		h.Get("Access-Control-Max-Age") != "3600" {
+		t.Fatalf("unexpected preflight headers: %v", h)
+	}
+
+	req.Header.Set("Origin", "https://evil.com")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("preflight from disallowed origin should be rejected, got %d", w.Code)
+	}
+}
+
+func TestCORSSimpleRequest(t *testing.T) {
+	r := newCORSEngine()
+	for origin, allowed := range map[string]bool{
+		"https://example.com":   true,
+		"http://localhost:8080": true,
+		"https://example.org":   false,
+		"https://evil.com":      false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || w.Body.String() != "users" {
+			t.Fatalf("handler should run for %s, got %d", origin, w.Code)
+		}
+		got := w.Header().Get("Access-Control-Allow-Origin") == origin
+		if got != allowed {
+			t.Fatalf("origin %s allowed = %v, want %v", origin, got, allowed)
+		}
+		if allowed && w.Header().Get("Access-Control-Expose-Headers") != "X-Total" {
+			t.Fatalf("expose headers should be set for %s", origin)
+		}
+	}
+}
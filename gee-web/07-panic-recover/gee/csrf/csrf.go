@@ -0,0 +1,254 @@
+package csrf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"gee-web/gee-web/07-panic-recover/gee/sessions"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// tokenLength 是原始 CSRF token 的字节数。
+const tokenLength = 32
+
+// contextKey 是当前请求的原始 token 在 gee.Context 中保存时使用的键。
+const contextKey = "gee-web/csrf"
+
+// fieldKey 是表单字段名在 gee.Context 中保存时使用的键，供 TemplateField 使用。
+const fieldKey = "gee-web/csrf-field"
+
+// Mode 表示 token 的保存方式。
+type Mode int
+
+const (
+	// PerSession 将 token 保存在会话中，需要在 CSRF 中间件之前使用 sessions.Sessions。
+	PerSession Mode = iota
+	// DoubleSubmit 将 token 保存在一个独立的 Cookie 中，请求时需要同时提交 Cookie 和 token。
+	DoubleSubmit
+)
+
+// Config 是 CSRF 中间件的配置。
+type Config struct {
+	Mode           Mode     // token 的保存方式，默认为 PerSession
+	SessionKey     string   // PerSession 模式下 token 在会话中的键，默认为 "csrf_token"
+	CookieName     string   // DoubleSubmit 模式下的 Cookie 名称，默认为 "_csrf"
+	CookiePath     string   // DoubleSubmit 模式下的 Cookie 路径，默认为 "/"
+	CookieSecure   bool     // DoubleSubmit 模式下 Cookie 是否仅通过 HTTPS 发送
+	HeaderName     string   // 提交 token 的请求头，默认为 "X-CSRF-Token"
+	FieldName      string   // 提交 token 的表单字段，默认为 "_csrf"
+	TrustedOrigins []string // 除本站外允许的来源，例如 "https://admin.example.com"
+	// SSLProxyHeaders 用于在终止 TLS 的反向代理之后判断请求是否为 HTTPS，例如 {"X-Forwarded-Proto": "https"}。
+	// 只应在代理会覆盖这些请求头时设置。
+	SSLProxyHeaders map[string]string
+	ExemptPaths     []string                // 不校验 token 的路径前缀，例如 API 分组 "/api"
+	ExemptFunc      func(*gee.Context) bool // 返回 true 时不校验 token
+}
+
+// safeMethods 是不会修改状态、不需要校验 token 的请求方法。
+var safeMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// New 返回 CSRF 中间件。
+// 中间件为每个请求准备 token，并在非安全方法的请求上依次校验来源和 token，校验失败时返回 403。
+func New(config Config) gee.HandlerFunc {
+	if config.SessionKey == "" {
+		config.SessionKey = "csrf_token"
+	}
+	if config.CookieName == "" {
+		config.CookieName = "_csrf"
+	}
+	if config.CookiePath == "" {
+		config.CookiePath = "/"
+	}
+	if config.HeaderName == "" {
+		config.HeaderName = "X-CSRF-Token"
+	}
+	if config.FieldName == "" {
+		config.FieldName = "_csrf"
+	}
+	return func(c *gee.Context) {
+		realToken, err := config.loadToken(c)
+		if err != nil {
+			c.Fail(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.Set(contextKey, realToken)
+		c.Set(fieldKey, config.FieldName)
+
+		if safeMethods[c.Method] || config.exempt(c) {
+			return
+		}
+		if err = config.checkOrigin(c.Req); err != nil {
+			c.Fail(http.StatusForbidden, err.Error())
+			return
+		}
+		sent := c.Req.Header.Get(config.HeaderName)
+		if sent == "" {
+			sent = c.PostForm(config.FieldName)
+		}
+		if !verify(realToken, sent) {
+			c.Fail(http.StatusForbidden, "CSRF token invalid")
+		}
+	}
+}
+
+// loadToken 返回当前请求的原始 token，不存在时生成一个新 token 并保存。
+func (config *Config) loadToken(c *gee.Context) ([]byte, error) {
+	if config.Mode == DoubleSubmit {
+		if v, err := c.Cookie(config.CookieName); err == nil {
+			if token, err := base64.RawURLEncoding.DecodeString(v); err == nil && len(token) == tokenLength {
+				return token, nil
+			}
+		}
+		token := randomBytes(tokenLength)
+		c.SetCookie(config.CookieName, base64.RawURLEncoding.EncodeToString(token), 0,
+			config.CookiePath, "", config.CookieSecure, false)
+		return token, nil
+	}
+
+	session := sessions.Default(c)
+	if v, ok := session.Get(config.SessionKey).([]byte); ok && len(v) == tokenLength {
+		return v, nil
+	}
+	token := randomBytes(tokenLength)
+	session.Set(config.SessionKey, token)
+	return token, session.Save()
+}
+
+// exempt 判断当前请求是否免于校验。
+func (config *Config) exempt(c *gee.Context) bool {
+	for _, prefix := range config.ExemptPaths {
+		if strings.HasPrefix(c.Path, prefix) {
+			return true
+		}
+	}
+	return config.ExemptFunc != nil && config.ExemptFunc(c)
+}
+
+// checkOrigin 校验请求来源：优先使用 Origin 头，其次使用 Referer 头。
+// 两者都缺失时，HTTPS 请求会被拒绝，HTTP 请求放行（部分客户端不发送这两个头）。
+func (config *Config) checkOrigin(req *http.Request) error {
+	scheme := "http"
+	if config.isHTTPS(req) {
+		scheme = "https"
+	}
+	self := scheme + "://" + req.Host
+
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		referer := req.Header.Get("Referer")
+		if referer == "" {
+			if scheme == "https" {
+				return fmt.Errorf("CSRF referer missing")
+			}
+			return nil
+		}
+		u, err := url.Parse(referer)
+		if err != nil {
+			return fmt.Errorf("CSRF referer invalid")
+		}
+		origin = u.Scheme + "://" + u.Host
+	}
+	if strings.EqualFold(origin, self) {
+		return nil
+	}
+	for _, trusted := range config.TrustedOrigins {
+		if strings.EqualFold(origin, trusted) {
+			return nil
+		}
+	}
+	return fmt.Errorf("CSRF origin %s not allowed", origin)
+}
+
+// isHTTPS 判断请求是否通过 HTTPS 到达。
+func (config *Config) isHTTPS(req *http.Request) bool {
+	if req.TLS != nil {
+		return true
+	}
+	for k, v := range config.SSLProxyHeaders {
+		if strings.EqualFold(req.Header.Get(k), v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Token 返回当前请求可以下发给客户端的 token。
+// 每次调用都会使用新的随机掩码，避免通过压缩长度泄露 token（BREACH）。
+func Token(c *gee.Context) string {
+	v, ok := c.Get(contextKey)
+	if !ok {
+		return ""
+	}
+	return mask(v.([]byte))
+}
+
+// TemplateField 返回包含 token 的隐藏表单字段，字段名为 Config.FieldName。
+func TemplateField(c *gee.Context) template.HTML {
+	name := c.GetString(fieldKey)
+	if name == "" {
+		name = "_csrf"
+	}
+	return template.HTML(fmt.Sprintf(`<input type="hidden" name="%s" value="%s">`, template.HTMLEscapeString(name), Token(c)))
+}
+
+// FuncMap 返回可以通过 Engine.SetFuncMap 注册的模板函数，需要在 LoadHTMLGlob 之前调用。
+// 模板数据中需要传入当前的 *gee.Context，例如：
+//
+//	c.HTMLTemplate(http.StatusOK, "form.tmpl", gee.H{"ctx": c})
+//	<form method="post">{{ csrfField .ctx }}</form>
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"csrfToken": Token,
+		"csrfField": TemplateField,
+	}
+}
+
+// mask 使用一次性随机掩码对 token 做异或，返回 base64(pad|token^pad)。
+func mask(token []byte) string {
+	pad := randomBytes(len(token))
+	masked := make([]byte, 0, 2*len(token))
+	masked = append(masked, pad...)
+	for i := range token {
+		masked = append(masked, token[i]^pad[i])
+	}
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+// verify 比较客户端提交的 token 与原始 token，支持带掩码和不带掩码（DoubleSubmit 直接读取 Cookie）两种形式。
+func verify(realToken []byte, sent string) bool {
+	b, err := base64.RawURLEncoding.DecodeString(sent)
+	if err != nil {
+		return false
+	}
+	switch len(b) {
+	case tokenLength:
+	case 2 * tokenLength:
+		pad, masked := b[:tokenLength], b[tokenLength:]
+		for i := range masked {
+			masked[i] ^= pad[i]
+		}
+		b = masked
+	default:
+		return false
+	}
+	return subtle.ConstantTimeCompare(b, realToken) == 1
+}
+
+// randomBytes 返回 n 个密码学安全的随机字节。
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic("csrf: failed to generate token: " + err.Error())
+	}
+	return b
+}
@@ -0,0 +1,116 @@
+package csrf
+
+import (
+	"gee-web/gee-web/07-panic-recover/gee"
+	"gee-web/gee-web/07-panic-recover/gee/geetest"
+	"gee-web/gee-web/07-panic-recover/gee/sessions"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// newTestEngine 创建一个使用 CSRF 中间件的 Engine，/form 渲染带 token 的表单。
+func newTestEngine(t *testing.T, config Config) *gee.Engine {
+	dir := t.TempDir()
+	tmpl := `<form method="post">{{ csrfField .ctx }}</form>`
+	if err := os.WriteFile(filepath.Join(dir, "form.tmpl"), []byte(tmpl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := gee.New()
+	r.SetFuncMap(FuncMap())
+	r.LoadHTMLGlob(filepath.Join(dir, "*"))
+	store := sessions.NewBackendStore(sessions.NewMemoryBackend(), []byte("0123456789abcdef0123456789abcdef"), nil)
+	r.Use(sessions.Sessions("geesession", store), New(config))
+	r.GET("/form", func(c *gee.Context) {
+		c.HTMLTemplate(http.StatusOK, "form.tmpl", gee.H{"ctx": c})
+	})
+	r.POST("/form", func(c *gee.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	r.POST("/api/form", func(c *gee.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	return r
+}
+
+var fieldRe = regexp.MustCompile(`name="_csrf" value="([^"]+)"`)
+
+// fetchToken 请求表单页并返回 token，会话 Cookie 保存在 client 中。
+func fetchToken(t *testing.T, client *geetest.Client) string {
+	w := client.GET("/form").Do()
+	m := fieldRe.FindStringSubmatch(w.Body.String())
+	if m == nil {
+		t.Fatalf("token not rendered: %s", w.Body.String())
+	}
+	return m[1]
+}
+
+func TestPerSession(t *testing.T) {
+	r := newTestEngine(t, Config{ExemptPaths: []string{"/api"}})
+	client := geetest.New(r)
+	token := fetchToken(t, client)
+
+	client.POST("/form").WithForm(url.Values{}).Expect(t).Status(http.StatusForbidden)
+	client.POST("/form").WithForm(url.Values{"_csrf": {token}}).Expect(t).Status(http.StatusOK)
+	client.POST("/form").WithForm(url.Values{}).WithHeader("X-CSRF-Token", token).Expect(t).Status(http.StatusOK)
+	// 另一个会话的 token
+	other := fetchToken(t, geetest.New(r))
+	client.POST("/form").WithForm(url.Values{"_csrf": {other}}).Expect(t).Status(http.StatusForbidden)
+	geetest.New(r).POST("/api/form").WithForm(url.Values{}).Expect(t).Status(http.StatusOK)
+}
+
+func TestOrigin(t *testing.T) {
+	client := geetest.New(newTestEngine(t, Config{TrustedOrigins: []string{"https://admin.example.com"}}))
+	form := url.Values{"_csrf": {fetchToken(t, client)}}
+
+	client.POST("/form").WithForm(form).WithHeader("Origin", "https://evil.com").Expect(t).Status(http.StatusForbidden)
+	client.POST("/form").WithForm(form).WithHeader("Referer", "https://evil.com/x").Expect(t).Status(http.StatusForbidden)
+	client.POST("/form").WithForm(form).WithHeader("Origin", "http://example.com").Expect(t).Status(http.StatusOK)
+	client.POST("/form").WithForm(form).WithHeader("Origin", "https://admin.example.com").Expect(t).Status(http.StatusOK)
+}
+
+func TestDoubleSubmit(t *testing.T) {
+	r := newTestEngine(t, Config{Mode: DoubleSubmit})
+	client := geetest.New(r)
+	w := client.GET("/form").Do()
+	m := fieldRe.FindStringSubmatch(w.Body.String())
+	var csrfCookie *http.Cookie
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "_csrf" {
+			csrfCookie = cookie
+		}
+	}
+	if m == nil || csrfCookie == nil {
+		t.Fatalf("token and double submit cookie should be set: %v %s", w.Header(), w.Body.String())
+	}
+
+	client.POST("/form").WithForm(url.Values{"_csrf": {m[1]}}).Expect(t).Status(http.StatusOK)
+	client.POST("/form").WithForm(url.Values{}).WithHeader("X-CSRF-Token", csrfCookie.Value).Expect(t).Status(http.StatusOK)
+	// 没有 Cookie 的客户端
+	geetest.New(r).POST("/form").WithForm(url.Values{"_csrf": {m[1]}}).Expect(t).Status(http.StatusForbidden)
+}
+
+func TestProxyHTTPS(t *testing.T) {
+	client := geetest.New(newTestEngine(t, Config{SSLProxyHeaders: map[string]string{"X-Forwarded-Proto": "https"}}))
+	form := url.Values{"_csrf": {fetchToken(t, client)}}
+
+	// 经过 TLS 代理的同源请求
+	client.POST("/form").WithForm(form).WithHeader("Origin", "https://example.com").WithHeader("X-Forwarded-Proto", "https").
+		Expect(t).Status(http.StatusOK)
+	// https 请求的 http Origin
+	client.POST("/form").WithForm(form).WithHeader("Origin", "http://example.com").WithHeader("X-Forwarded-Proto", "https").
+		Expect(t).Status(http.StatusForbidden)
+}
+
+func TestCustomFieldName(t *testing.T) {
+	client := geetest.New(newTestEngine(t, Config{FieldName: "authenticity_token"}))
+	w := client.GET("/form").Do()
+	m := regexp.MustCompile(`name="authenticity_token" value="([^"]+)"`).FindStringSubmatch(w.Body.String())
+	if m == nil {
+		t.Fatalf("field should use the configured name: %s", w.Body.String())
+	}
+	client.POST("/form").WithForm(url.Values{"authenticity_token": {m[1]}}).Expect(t).Status(http.StatusOK)
+}
@@ -2,10 +2,9 @@ package gee
 
 import (
 	"html/template"
-	"log"
 	"net/http"
-	"path"
 	"strings"
+	"sync/atomic"
 )
 
 // HandlerFunc 定义了gee使用的请求处理函数。
@@ -20,21 +19,41 @@ type (
 		middlewares []HandlerFunc // 该组的中间件函数。
 		parent      *RouterGroup  // 父组，支持嵌套。
 		engine      *Engine       // 引用Engine实例，所有组共享。
+		host        *hostRouter   // 所属的主机，为nil时属于默认主机。
 	}
 
 	Engine struct {
 		*RouterGroup                     // 嵌入的RouterGroup，用于Engine。
-		router        *router            // 用于处理请求路由的路由器。
+		router        *router            // 用于处理请求路由的路由器（默认主机）。
+		hosts         []*hostRouter      // 按主机名划分的路由器。
 		groups        []*RouterGroup     // 存储所有RouterGroup。
+		routes        []*Route           // 按注册顺序保存的路由及其文档元数据。
 		htmlTemplates *template.Template // for html render
+		htmlPattern   string             // 模板文件的匹配模式，DebugMode 下用于热加载
 		funcMap       template.FuncMap   // for html render
+		// MaxMultipartMemory 是解析 multipart 表单时保存在内存中的最大字节数，超出部分写入临时文件。
+		MaxMultipartMemory int64
+		noRoute            HandlerFunc // 没有匹配到路由时的处理函数
 	}
 )
 
+// debugModeWarned 记录是否已经输出过 DebugMode 的提示，每个进程只输出一次。
+var debugModeWarned atomic.Bool
+
 // New 是gee.Engine的构造函数。
 // 它初始化一个新的Engine实例，带有新的路由器和默认的RouterGroup。
 func New() *Engine {
-	engine := &Engine{router: newRouter()}
+	if IsDebugging() && debugModeWarned.CompareAndSwap(false, true) {
+		debugPrintWarning(`Running in "debug" mode. Switch to "release" mode in production.
+ - using env:	export GEE_MODE=release
+ - using code:	gee.SetMode(gee.ReleaseMode)`)
+	}
+	return newEngine()
+}
+
+// newEngine 创建Engine实例，不输出 DebugMode 的提示。
+func newEngine() *Engine {
+	engine := &Engine{router: newRouter(), MaxMultipartMemory: defaultMultipartMemory}
 	engine.RouterGroup = &RouterGroup{engine: engine}
 	engine.groups = []*RouterGroup{engine.RouterGroup}
 	return engine
@@ -61,6 +80,7 @@ func (group *RouterGroup) Group(prefix string) *RouterGroup {
 		prefix: group.prefix + prefix,
 		parent: group,
 		engine: engine,
+		host:   group.host,
 	}
 	engine.groups = append(engine.groups, newGroup)
 	return newGroup
@@ -78,50 +98,106 @@ func (group *RouterGroup) Use(middlewares ...HandlerFunc) {
 //   - method: HTTP方法（如GET、POST）。
 //   - comp: 路径组件。
 //   - handler: 处理该路由的HandlerFunc。
-func (group *RouterGroup) addRoute(method string, comp string, handler HandlerFunc) {
+//
+// 返回:
+//   - *Route: 注册的路由，可以继续设置文档元数据。
+func (group *RouterGroup) addRoute(method string, comp string, handler HandlerFunc) *Route {
 	pattern := group.prefix + comp
-	log.Printf("Route %4s - %s", method, pattern)
+	route := &Route{Method: method, Path: pattern, Handler: nameOfFunction(handler)}
+	group.engine.routes = append(group.engine.routes, route)
+	if group.host != nil {
+		route.Host = group.host.pattern
+		debugPrintRoute(method, group.host.pattern+pattern, handler)
+		group.host.router.addRoute(method, pattern, handler)
+		return route
+	}
+	debugPrintRoute(method, pattern, handler)
 	group.engine.router.addRoute(method, pattern, handler)
+	return route
 }
 
 // GET 用于添加GET请求。
 // 参数:
 //   - pattern: 请求路径模式。
 //   - handler: 处理该请求的HandlerFunc。
-func (group *RouterGroup) GET(pattern string, handler HandlerFunc) {
-	group.addRoute("GET", pattern, handler)
+//
+// 返回:
+//   - *Route: 注册的路由，可以继续设置文档元数据。
+func (group *RouterGroup) GET(pattern string, handler HandlerFunc) *Route {
+	return group.addRoute("GET", pattern, handler)
 }
 
 // POST 用于添加POST请求。
 // 参数:
 //   - pattern: 请求路径模式。
 //   - handler: 处理该请求的HandlerFunc。
-func (group *RouterGroup) POST(pattern string, handler HandlerFunc) {
-	group.addRoute("POST", pattern, handler)
-}
-
-// createStaticHandler 创建一个处理静态文件的HandlerFunc。
-func (group *RouterGroup) createStaticHandler(relativePath string, fs http.FileSystem) HandlerFunc {
-	absolutePath := path.Join(group.prefix, relativePath)
-	fileServer := http.StripPrefix(absolutePath, http.FileServer(fs))
-	return func(c *Context) {
-		file := c.Param("filepath")
-		// Check if file exists and/or if we have permission to access it
-		if _, err := fs.Open(file); err != nil {
-			c.Status(http.StatusNotFound)
-			return
-		}
+//
+// 返回:
+//   - *Route: 注册的路由，可以继续设置文档元数据。
+func (group *RouterGroup) POST(pattern string, handler HandlerFunc) *Route {
+	return group.addRoute("POST", pattern, handler)
+}
 
-		fileServer.ServeHTTP(c.Writer, c.Req)
-	}
+// PUT 用于添加PUT请求。
+// 参数:
+//   - pattern: 请求路径模式。
+//   - handler: 处理该请求的HandlerFunc。
+//
+// 返回:
+//   - *Route: 注册的路由，可以继续设置文档元数据。
+func (group *RouterGroup) PUT(pattern string, handler HandlerFunc) *Route {
+	return group.addRoute("PUT", pattern, handler)
 }
 
-// Static 用于处理静态文件。
-func (group *RouterGroup) Static(relativePath string, root string) {
-	handler := group.createStaticHandler(relativePath, http.Dir(root))
-	urlPattern := path.Join(relativePath, "/*filepath")
-	// Register GET handlers
-	group.GET(urlPattern, handler)
+// PATCH 用于添加PATCH请求。
+// 参数:
+//   - pattern: 请求路径模式。
+//   - handler: 处理该请求的HandlerFunc。
+//
+// 返回:
+//   - *Route: 注册的路由，可以继续设置文档元数据。
+func (group *RouterGroup) PATCH(pattern string, handler HandlerFunc) *Route {
+	return group.addRoute("PATCH", pattern, handler)
+}
+
+// DELETE 用于添加DELETE请求。
+// 参数:
+//   - pattern: 请求路径模式。
+//   - handler: 处理该请求的HandlerFunc。
+//
+// 返回:
+//   - *Route: 注册的路由，可以继续设置文档元数据。
+func (group *RouterGroup) DELETE(pattern string, handler HandlerFunc) *Route {
+	return group.addRoute("DELETE", pattern, handler)
+}
+
+// HEAD 用于添加HEAD请求。
+// 参数:
+//   - pattern: 请求路径模式。
+//   - handler: 处理该请求的HandlerFunc。
+//
+// 返回:
+//   - *Route: 注册的路由，可以继续设置文档元数据。
+func (group *RouterGroup) HEAD(pattern string, handler HandlerFunc) *Route {
+	return group.addRoute("HEAD", pattern, handler)
+}
+
+// OPTIONS 用于添加OPTIONS请求。
+// 参数:
+//   - pattern: 请求路径模式。
+//   - handler: 处理该请求的HandlerFunc。
+//
+// 返回:
+//   - *Route: 注册的路由，可以继续设置文档元数据。
+func (group *RouterGroup) OPTIONS(pattern string, handler HandlerFunc) *Route {
+	return group.addRoute("OPTIONS", pattern, handler)
+}
+
+// NoRoute 设置没有匹配到路由时的处理函数，默认返回 404 纯文本。
+// 参数:
+//   - handler: 处理函数，分组中间件会在它之前执行。
+func (engine *Engine) NoRoute(handler HandlerFunc) {
+	engine.noRoute = handler
 }
 
 // SetFuncMap 用于设置模板函数。
@@ -130,10 +206,20 @@ func (engine *Engine) SetFuncMap(funcMap template.FuncMap) {
 }
 
 // LoadHTMLGlob 用于加载HTML模板。
+// DebugMode 下每次渲染前都会重新加载模板，修改模板文件后无需重启。
 func (engine *Engine) LoadHTMLGlob(pattern string) {
+	engine.htmlPattern = pattern
 	engine.htmlTemplates = template.Must(template.New("").Funcs(engine.funcMap).ParseGlob(pattern))
 }
 
+// templates 返回用于渲染的模板，DebugMode 下会重新解析模板文件。
+func (engine *Engine) templates() (*template.Template, error) {
+	if IsDebugging() && engine.htmlPattern != "" {
+		return template.New("").Funcs(engine.funcMap).ParseGlob(engine.htmlPattern)
+	}
+	return engine.htmlTemplates, nil
+}
+
 // Run 用于启动HTTP服务器。
 // 参数:
 //   - addr: 监听地址。
@@ -141,6 +227,7 @@ func (engine *Engine) LoadHTMLGlob(pattern string) {
 // 返回:
 //   - err: 启动过程中可能发生的错误。
 func (engine *Engine) Run(addr string) (err error) {
+	debugPrint("Listening and serving HTTP on %s", addr)
 	return http.ListenAndServe(addr, engine)
 }
 
@@ -150,8 +237,13 @@ func (engine *Engine) Run(addr string) (err error) {
 //   - w: HTTP响应写入器。
 //   - req: HTTP请求。
 func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	host, hostParams := engine.matchHost(req.Host)
 	var middlewares []HandlerFunc
 	for _, group := range engine.groups {
+		// Engine自身的中间件对所有主机生效，其他分组只对所属主机生效。
+		if group != engine.RouterGroup && group.host != host {
+			continue
+		}
 		if strings.HasPrefix(req.URL.Path, group.prefix) {
 			middlewares = append(middlewares, group.middlewares...)
 		}
@@ -159,5 +251,10 @@ func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	c := newContext(w, req)
 	c.handlers = middlewares
 	c.engine = engine
+	c.Params = hostParams
+	if host != nil {
+		host.router.handle(c)
+		return
+	}
 	engine.router.handle(c)
 }
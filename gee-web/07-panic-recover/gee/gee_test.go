@@ -1,6 +1,23 @@
 package gee
 
-import "testing"
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// performRequest 在进程内执行一个请求并返回响应记录，header 为成对的请求头名称和值。
+// 包 gee 的测试不能导入 geetest（geetest 依赖 gee，会形成导入循环），因此使用这个辅助函数。
+func performRequest(r http.Handler, method, target, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
 
 func TestNestedGroup(t *testing.T) {
 	r := New()
@@ -0,0 +1,145 @@
+package geetest
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TB 是 testing.TB 中断言用到的方法，便于替换测试中的 *testing.T。
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Response 用于对响应进行链式断言，断言失败时调用 t.Errorf，后续断言仍会执行。
+type Response struct {
+	t        TB
+	Recorder *httptest.ResponseRecorder
+}
+
+// Status 断言状态码。
+func (r *Response) Status(code int) *Response {
+	r.t.Helper()
+	if r.Recorder.Code != code {
+		r.t.Errorf("status: expect %d, got %d, body: %s", code, r.Recorder.Code, r.Recorder.Body.String())
+	}
+	return r
+}
+
+// Header 断言响应头的值。
+func (r *Response) Header(key, value string) *Response {
+	r.t.Helper()
+	if got := r.Recorder.Header().Get(key); got != value {
+		r.t.Errorf("header %s: expect %q, got %q", key, value, got)
+	}
+	return r
+}
+
+// Body 断言响应体。
+func (r *Response) Body(body string) *Response {
+	r.t.Helper()
+	if got := r.Recorder.Body.String(); got != body {
+		r.t.Errorf("body: expect %q, got %q", body, got)
+	}
+	return r
+}
+
+// BodyContains 断言响应体包含 substr。
+func (r *Response) BodyContains(substr string) *Response {
+	r.t.Helper()
+	if got := r.Recorder.Body.String(); !strings.Contains(got, substr) {
+		r.t.Errorf("body: expect to contain %q, got %q", substr, got)
+	}
+	return r
+}
+
+// Cookie 断言响应设置了 Cookie 且值为 value。
+func (r *Response) Cookie(name, value string) *Response {
+	r.t.Helper()
+	for _, cookie := range r.Recorder.Result().Cookies() {
+		if cookie.Name == name {
+			if cookie.Value != value {
+				r.t.Errorf("cookie %s: expect %q, got %q", name, value, cookie.Value)
+			}
+			return r
+		}
+	}
+	r.t.Errorf("cookie %s: not set", name)
+	return r
+}
+
+// JSON 断言响应体是与 want 等价的 JSON。
+func (r *Response) JSON(want interface{}) *Response {
+	r.t.Helper()
+	var got interface{}
+	if err := json.Unmarshal(r.Recorder.Body.Bytes(), &got); err != nil {
+		r.t.Errorf("json: %v, body: %s", err, r.Recorder.Body.String())
+		return r
+	}
+	if w := normalize(want); !reflect.DeepEqual(got, w) {
+		r.t.Errorf("json: expect %v, got %v", w, got)
+	}
+	return r
+}
+
+// JSONPath 断言响应 JSON 中 path 处的值与 want 等价。
+// path 使用 "." 分隔，数组下标使用数字，例如 "data.items.0.name"。
+func (r *Response) JSONPath(path string, want interface{}) *Response {
+	r.t.Helper()
+	var doc interface{}
+	if err := json.Unmarshal(r.Recorder.Body.Bytes(), &doc); err != nil {
+		r.t.Errorf("json: %v, body: %s", err, r.Recorder.Body.String())
+		return r
+	}
+	got, ok := lookup(doc, path)
+	if !ok {
+		r.t.Errorf("json path %s: not found in %s", path, r.Recorder.Body.String())
+		return r
+	}
+	if w := normalize(want); !reflect.DeepEqual(got, w) {
+		r.t.Errorf("json path %s: expect %v, got %v", path, w, got)
+	}
+	return r
+}
+
+// lookup 按路径查找 JSON 文档中的值。
+func lookup(doc interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return doc, true
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			next, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+			doc = next
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			doc = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// normalize 将 v 经过一次 JSON 编解码，使 int 与 float64、struct 与 map 等可以直接比较。
+func normalize(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err = json.Unmarshal(b, &out); err != nil {
+		return v
+	}
+	return out
+}
@@ -0,0 +1,207 @@
+package geetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Client 在进程内直接调用 http.Handler（通常是 *gee.Engine）的 ServeHTTP，不经过网络。
+// Client 会保存响应中的 Cookie 并在之后的请求中带上，便于测试会话等功能。
+type Client struct {
+	handler http.Handler
+	header  http.Header
+
+	mu      sync.Mutex
+	cookies map[string]*http.Cookie
+}
+
+// New 创建一个 Client。
+func New(handler http.Handler) *Client {
+	return &Client{
+		handler: handler,
+		header:  make(http.Header),
+		cookies: make(map[string]*http.Cookie),
+	}
+}
+
+// WithHeader 设置之后每个请求默认携带的请求头。
+func (cl *Client) WithHeader(key, value string) *Client {
+	cl.header.Set(key, value)
+	return cl
+}
+
+// GET 创建一个 GET 请求。
+func (cl *Client) GET(path string) *Request {
+	return cl.Request(http.MethodGet, path)
+}
+
+// POST 创建一个 POST 请求。
+func (cl *Client) POST(path string) *Request {
+	return cl.Request(http.MethodPost, path)
+}
+
+// PUT 创建一个 PUT 请求。
+func (cl *Client) PUT(path string) *Request {
+	return cl.Request(http.MethodPut, path)
+}
+
+// PATCH 创建一个 PATCH 请求。
+func (cl *Client) PATCH(path string) *Request {
+	return cl.Request(http.MethodPatch, path)
+}
+
+// DELETE 创建一个 DELETE 请求。
+func (cl *Client) DELETE(path string) *Request {
+	return cl.Request(http.MethodDelete, path)
+}
+
+// HEAD 创建一个 HEAD 请求。
+func (cl *Client) HEAD(path string) *Request {
+	return cl.Request(http.MethodHead, path)
+}
+
+// OPTIONS 创建一个 OPTIONS 请求。
+func (cl *Client) OPTIONS(path string) *Request {
+	return cl.Request(http.MethodOptions, path)
+}
+
+// Request 创建一个指定方法的请求。
+func (cl *Client) Request(method, path string) *Request {
+	return &Request{
+		client: cl,
+		method: method,
+		path:   path,
+		header: cl.header.Clone(),
+		query:  make(url.Values),
+	}
+}
+
+// saveCookies 保存响应中的 Cookie，MaxAge 小于 0 的 Cookie 会被删除。
+func (cl *Client) saveCookies(cookies []*http.Cookie) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	for _, cookie := range cookies {
+		if cookie.MaxAge < 0 {
+			delete(cl.cookies, cookie.Name)
+		} else {
+			cl.cookies[cookie.Name] = cookie
+		}
+	}
+}
+
+// Request 是一个待发送的请求，通过链式调用设置请求内容。
+type Request struct {
+	client  *Client
+	method  string
+	path    string
+	header  http.Header
+	query   url.Values
+	cookies []*http.Cookie
+	body    io.Reader
+}
+
+// WithHeader 设置请求头。
+func (r *Request) WithHeader(key, value string) *Request {
+	r.header.Set(key, value)
+	return r
+}
+
+// WithQuery 添加查询参数。
+func (r *Request) WithQuery(key, value string) *Request {
+	r.query.Add(key, value)
+	return r
+}
+
+// WithCookie 添加 Cookie。
+func (r *Request) WithCookie(name, value string) *Request {
+	r.cookies = append(r.cookies, &http.Cookie{Name: name, Value: value})
+	return r
+}
+
+// WithBasicAuth 设置 HTTP Basic 认证。
+func (r *Request) WithBasicAuth(user, password string) *Request {
+	req := http.Request{Header: r.header}
+	req.SetBasicAuth(user, password)
+	return r
+}
+
+// WithBody 设置请求体和 Content-Type。
+func (r *Request) WithBody(contentType string, body io.Reader) *Request {
+	r.header.Set("Content-Type", contentType)
+	r.body = body
+	return r
+}
+
+// WithJSON 将 v 编码为 JSON 作为请求体。
+func (r *Request) WithJSON(v interface{}) *Request {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic("geetest: " + err.Error())
+	}
+	return r.WithBody("application/json", bytes.NewReader(b))
+}
+
+// WithForm 将 form 编码为 application/x-www-form-urlencoded 作为请求体。
+func (r *Request) WithForm(form url.Values) *Request {
+	return r.WithBody("application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+}
+
+// HTTPRequest 构造对应的 *http.Request。
+func (r *Request) HTTPRequest() *http.Request {
+	target := r.path
+	if len(r.query) > 0 {
+		sep := "?"
+		if strings.Contains(target, "?") {
+			sep = "&"
+		}
+		target += sep + r.query.Encode()
+	}
+	req := httptest.NewRequest(r.method, target, r.body)
+	req.Header = r.header.Clone()
+	r.client.mu.Lock()
+	for _, cookie := range r.client.cookies {
+		req.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+	}
+	r.client.mu.Unlock()
+	for _, cookie := range r.cookies {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+// Do 发送请求并返回响应记录。
+func (r *Request) Do() *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.client.handler.ServeHTTP(w, r.HTTPRequest())
+	r.client.saveCookies(w.Result().Cookies())
+	return w
+}
+
+// Expect 发送请求，并返回用于断言响应的 Response。
+func (r *Request) Expect(t TB) *Response {
+	t.Helper()
+	return &Response{t: t, Recorder: r.Do()}
+}
+
+// NewContext 创建一个不经过路由的 *gee.Context，用于单元测试中间件或处理函数。
+// handlers 是依次执行的处理链，调用 c.Next() 开始执行。
+func NewContext(req *http.Request, handlers ...gee.HandlerFunc) (*gee.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := gee.CreateTestContext(w, req, handlers...)
+	return c, w
+}
+
+// RunMiddleware 依次执行 middleware 和 handlers 组成的处理链，返回处理后的 Context 和响应记录。
+// 可以通过 c.IsAborted() 判断中间件是否中断了请求。
+func RunMiddleware(req *http.Request, middleware gee.HandlerFunc, handlers ...gee.HandlerFunc) (*gee.Context, *httptest.ResponseRecorder) {
+	c, w := NewContext(req, append([]gee.HandlerFunc{middleware}, handlers...)...)
+	c.Next()
+	return c, w
+}
@@ -0,0 +1,84 @@
+package geetest
+
+import (
+	"fmt"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeTB 记录断言失败信息，用于测试断言本身。
+type fakeTB struct {
+	errors []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func newTestEngine() *gee.Engine {
+	r := gee.New()
+	r.GET("/users/:name", func(c *gee.Context) {
+		c.SetCookie("last", c.Param("name"), 0, "", "", false, true)
+		c.JSON(http.StatusOK, gee.H{
+			"name":  c.Param("name"),
+			"token": c.Req.Header.Get("X-Token"),
+			"page":  c.Query("page"),
+			"roles": []string{"admin", "dev"},
+			"meta":  gee.H{"age": 18},
+		})
+	})
+	r.GET("/last", func(c *gee.Context) {
+		last, _ := c.Cookie("last")
+		c.String(http.StatusOK, "%s", last)
+	})
+	return r
+}
+
+func TestClient(t *testing.T) {
+	client := New(newTestEngine()).WithHeader("X-Token", "secret")
+	client.GET("/users/geektutu").WithQuery("page", "2").Expect(t).
+		Status(http.StatusOK).
+		Header("Content-Type", "application/json").
+		Cookie("last", "geektutu").
+		JSONPath("name", "geektutu").
+		JSONPath("token", "secret").
+		JSONPath("page", "2").
+		JSONPath("roles.1", "dev").
+		JSONPath("meta.age", 18)
+	client.GET("/last").Expect(t).Body("geektutu")
+}
+
+func TestAssertionFailures(t *testing.T) {
+	ft := &fakeTB{}
+	New(newTestEngine()).GET("/users/geektutu").Expect(ft).
+		Status(http.StatusNotFound).
+		JSONPath("meta.age", 19).
+		JSONPath("roles.5", "x").
+		BodyContains("nothing")
+	if len(ft.errors) != 4 {
+		t.Fatalf("expect 4 failures, got %d: %v", len(ft.errors), ft.errors)
+	}
+}
+
+func TestRunMiddleware(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth("admin", "secret")
+	var user string
+	c, w := RunMiddleware(req, gee.BasicAuth(gee.Accounts{"admin": "secret"}), func(c *gee.Context) {
+		user = c.GetString(gee.AuthUserKey)
+	})
+	if user != "admin" || c.GetString(gee.AuthUserKey) != "admin" || w.Code != http.StatusOK {
+		t.Fatalf("handler should see user admin, got %q", user)
+	}
+
+	called := false
+	_, w = RunMiddleware(httptest.NewRequest(http.MethodGet, "/", nil), gee.BasicAuth(gee.Accounts{"admin": "secret"}),
+		func(c *gee.Context) { called = true })
+	if called || w.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthorized request should stop the chain, got %d", w.Code)
+	}
+}
@@ -0,0 +1,326 @@
+package gee
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// GzipConfig 是压缩中间件的配置。
+type GzipConfig struct {
+	// Level 是压缩级别，取值同 compress/gzip，例如 gzip.DefaultCompression。
+	Level int
+	// MinLength 是触发压缩的最小响应长度，默认为 1024 字节。
+	MinLength int
+	// ContentTypes 是可压缩的 Content-Type 前缀，默认为文本、JSON、JavaScript、XML 和 SVG。
+	ContentTypes []string
+	// ExcludedPaths 是不压缩的路径前缀。
+	ExcludedPaths []string
+	// ExcludedExtensions 是不压缩的文件扩展名，默认为常见的图片、音视频和压缩包格式。
+	ExcludedExtensions []string
+	// MaxDecompressedSize 是解压后请求体的最大字节数，默认为 10 MB，小于 0 时不限制。
+	// BodyLimit 只能限制压缩后的长度，少量压缩数据可以解压出极大的请求体。
+	// 超过限制时读取请求体返回 *http.MaxBytesError，Bind 返回 413。
+	MaxDecompressedSize int64
+}
+
+var (
+	defaultCompressibleTypes = []string{
+		"text/", "application/json", "application/javascript", "application/xml",
+		"application/x-javascript", "image/svg+xml",
+	}
+	defaultExcludedExtensions = []string{
+		".png", ".gif", ".jpeg", ".jpg", ".webp", ".mp3", ".mp4", ".zip", ".gz", ".br",
+	}
+)
+
+// Gzip 返回压缩中间件，使用默认配置和指定的压缩级别。
+// 参数:
+//   - level: 压缩级别，例如 gzip.DefaultCompression、gzip.BestSpeed。
+func Gzip(level int) HandlerFunc {
+	return GzipWithConfig(GzipConfig{Level: level})
+}
+
+// GzipWithConfig 返回压缩中间件。
+// 根据 Accept-Encoding 对响应进行 gzip 或 deflate 压缩，并透明地解压 Content-Encoding 为 gzip 或 deflate 的请求体。
+func GzipWithConfig(config GzipConfig) HandlerFunc {
+	if _, err := gzip.NewWriterLevel(io.Discard, config.Level); err != nil {
+		panic("gee: invalid gzip level " + strconv.Itoa(config.Level))
+	}
+	if config.MinLength == 0 {
+		config.MinLength = 1024
+	}
+	if config.ContentTypes == nil {
+		config.ContentTypes = defaultCompressibleTypes
+	}
+	if config.ExcludedExtensions == nil {
+		config.ExcludedExtensions = defaultExcludedExtensions
+	}
+	if config.MaxDecompressedSize == 0 {
+		config.MaxDecompressedSize = 10 << 20
+	}
+	excludedExtensions := make(map[string]bool, len(config.ExcludedExtensions))
+	for _, ext := range config.ExcludedExtensions {
+		excludedExtensions[strings.ToLower(ext)] = true
+	}
+	pools := map[string]*sync.Pool{
+		"gzip": {New: func() interface{} {
+			w, _ := gzip.NewWriterLevel(io.Discard, config.Level)
+			return w
+		}},
+		"deflate": {New: func() interface{} {
+			w, _ := zlib.NewWriterLevel(io.Discard, config.Level)
+			return w
+		}},
+	}
+
+	return func(c *Context) {
+		if err := decompressRequest(c.Writer, c.Req, config.MaxDecompressedSize); err != nil {
+			c.Fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		for _, prefix := range config.ExcludedPaths {
+			if strings.HasPrefix(c.Path, prefix) {
+				return
+			}
+		}
+		if excludedExtensions[strings.ToLower(path.Ext(c.Path))] {
+			return
+		}
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(c.Req.Header.Get("Accept-Encoding"))
+		if encoding == "" || c.Method == http.MethodHead {
+			return
+		}
+
+		cw := &compressWriter{
+			ResponseWriter: c.Writer,
+			config:         &config,
+			encoding:       encoding,
+			pool:           pools[encoding],
+		}
+		c.Writer = cw
+		defer func() {
+			cw.close()
+			c.Writer = cw.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// decompressRequest 将 gzip 或 deflate 编码的请求体替换为解压后的读取器，limit 大于 0 时限制解压后的长度。
+func decompressRequest(w http.ResponseWriter, req *http.Request, limit int64) error {
+	var body io.ReadCloser
+	var err error
+	switch strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		body, err = gzip.NewReader(req.Body)
+	case "deflate":
+		body, err = zlib.NewReader(req.Body)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if limit > 0 {
+		body = http.MaxBytesReader(w, body, limit)
+	}
+	req.Body = body
+	req.Header.Del("Content-Encoding")
+	req.Header.Del("Content-Length")
+	req.ContentLength = -1
+	return nil
+}
+
+// negotiateEncoding 根据 Accept-Encoding 选择压缩方式，优先 gzip，不支持时返回空字符串。
+func negotiateEncoding(accept string) string {
+	q := parseAccept(accept)
+	for _, encoding := range []string{"gzip", "deflate"} {
+		if acceptsEncoding(q, encoding) {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// acceptsEncoding 判断客户端是否接受指定的编码，q=0 表示明确拒绝。
+func acceptsEncoding(q map[string]float64, encoding string) bool {
+	weight, ok := q[encoding]
+	if !ok {
+		weight, ok = q["*"]
+	}
+	return ok && weight > 0
+}
+
+// parseAccept 解析 Accept、Accept-Encoding 等带权重的请求头，返回值（小写）到权重的映射。
+func parseAccept(accept string) map[string]float64 {
+	q := make(map[string]float64)
+	for _, item := range strings.Split(accept, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, params, _ := strings.Cut(item, ";")
+		name, weight := strings.TrimSpace(name), 1.0
+		// 媒体类型可能带有 q 以外的参数，例如 "text/html;level=1;q=0.5"
+		for _, param := range strings.Split(params, ";") {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					weight = v
+				}
+			}
+		}
+		q[strings.ToLower(name)] = weight
+	}
+	return q
+}
+
+// compressor 是 gzip.Writer 和 zlib.Writer 的公共方法。
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// compressWriter 包装 http.ResponseWriter，在确定响应可压缩后对输出进行压缩。
+// 响应在达到 MinLength 之前先缓存在内存中，以便根据长度和 Content-Type 决定是否压缩。
+type compressWriter struct {
+	http.ResponseWriter
+	config   *GzipConfig
+	encoding string
+	pool     *sync.Pool
+
+	code    int
+	buf     bytes.Buffer
+	decided bool       // 是否已经决定了是否压缩
+	cw      compressor // 为 nil 表示不压缩
+}
+
+// WriteHeader 记录状态码，直到决定是否压缩后再写出。
+func (w *compressWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+// Write 实现 http.ResponseWriter 接口。
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	if !w.decided {
+		w.buf.Write(b)
+		if w.buf.Len() < w.config.MinLength {
+			return len(b), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.cw != nil {
+		return w.cw.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush 实现 http.Flusher 接口，流式响应在第一次 Flush 时即决定是否压缩。
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if w.code == 0 {
+			w.code = http.StatusOK
+		}
+		if err := w.decide(true); err != nil {
+			return
+		}
+	}
+	if w.cw != nil {
+		_ = w.cw.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap 返回被包装的 http.ResponseWriter，使 http.ResponseController 可以使用 Hijack 等方法。
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide 决定是否压缩，写出响应头和已缓存的数据。
+// allowCompress 为 false 时（响应在关闭前都没有达到 MinLength）不压缩。
+func (w *compressWriter) decide(allowCompress bool) error {
+	w.decided = true
+	header := w.ResponseWriter.Header()
+	if header.Get("Content-Type") == "" && w.buf.Len() > 0 {
+		header.Set("Content-Type", http.DetectContentType(w.buf.Bytes()))
+	}
+	if allowCompress && w.compressible(header) {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", w.encoding)
+		w.cw = w.pool.Get().(compressor)
+		w.cw.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.code)
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.cw != nil {
+		_, err = w.cw.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+// compressible 判断当前响应是否应当压缩。
+func (w *compressWriter) compressible(header http.Header) bool {
+	if w.code < http.StatusOK || w.code == http.StatusNoContent ||
+		w.code == http.StatusNotModified || w.code == http.StatusPartialContent {
+		return false
+	}
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	for _, prefix := range w.config.ContentTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// close 写出剩余的数据，并将压缩器放回对象池。
+func (w *compressWriter) close() {
+	if !w.decided {
+		if w.code == 0 {
+			// 处理函数没有写出任何内容
+			return
+		}
+		_ = w.decide(false)
+	}
+	if w.cw != nil {
+		_ = w.cw.Close()
+		w.cw.Reset(io.Discard)
+		w.pool.Put(w.cw)
+		w.cw = nil
+	}
+}
+
+var _ http.Flusher = (*compressWriter)(nil)
+
+var (
+	_ compressor = (*gzip.Writer)(nil)
+	_ compressor = (*zlib.Writer)(nil)
+)
@@ -0,0 +1,136 @@
+package gee
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newGzipEngine() *Engine {
+	r := New()
+	r.Use(GzipWithConfig(GzipConfig{Level: gzip.BestSpeed, ExcludedPaths: []string{"/raw"}}))
+	r.GET("/json", func(c *Context) {
+		c.JSON(http.StatusOK, H{"data": strings.Repeat("gee", 1000)})
+	})
+	r.GET("/small", func(c *Context) {
+		c.String(http.StatusOK, "small")
+	})
+	r.GET("/raw", func(c *Context) {
+		c.String(http.StatusOK, "%s", strings.Repeat("gee", 1000))
+	})
+	r.GET("/stream", func(c *Context) {
+		c.SetHeader("Content-Type", "text/event-stream")
+		c.Writer.Write([]byte("data: 1\n\n"))
+		c.Writer.(http.Flusher).Flush()
+		c.Writer.Write([]byte("data: 2\n\n"))
+	})
+	r.GET("/hijack", func(c *Context) {
+		conn, rw, err := http.NewResponseController(c.Writer).Hijack()
+		if err != nil {
+			c.String(http.StatusInternalServerError, "%v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		rw.Flush()
+	})
+	r.POST("/echo", func(c *Context) {
+		body, _ := io.ReadAll(c.Req.Body)
+		c.Data(http.StatusOK, body)
+	})
+	return r
+}
+
+func gunzip(t *testing.T, b []byte) string {
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestGzip(t *testing.T) {
+	r := newGzipEngine()
+	w := performRequest(r, http.MethodGet, "/json", "", "Accept-Encoding", "br;q=1.0, gzip;q=0.8")
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("json should be compressed, got %v", w.Header())
+	}
+	if body := gunzip(t, w.Body.Bytes()); !strings.HasPrefix(body, `{"data":"geegee`) {
+		t.Fatalf("unexpected body %q", body)
+	}
+
+	for _, path := range []string{"/small", "/raw"} {
+		w = performRequest(r, http.MethodGet, path, "", "Accept-Encoding", "br;q=1.0, gzip;q=0.8")
+		if w.Header().Get("Content-Encoding") != "" || w.Code != http.StatusOK {
+			t.Fatalf("%s should not be compressed", path)
+		}
+	}
+
+	w = performRequest(r, http.MethodGet, "/stream", "", "Accept-Encoding", "br;q=1.0, gzip;q=0.8")
+	if w.Header().Get("Content-Encoding") != "gzip" || !w.Flushed {
+		t.Fatal("stream should be compressed and flushed")
+	}
+	if body := gunzip(t, w.Body.Bytes()); body != "data: 1\n\ndata: 2\n\n" {
+		t.Fatalf("unexpected stream body %q", body)
+	}
+
+	w = performRequest(r, http.MethodGet, "/json", "", "Accept-Encoding", "gzip;q=0, identity")
+	if w.Header().Get("Content-Encoding") != "" {
+		t.Fatal("gzip;q=0 should disable compression")
+	}
+}
+
+func TestGzipRequest(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte("hello gee"))
+	zw.Close()
+
+	w := performRequest(newGzipEngine(), http.MethodPost, "/echo", buf.String(), "Content-Encoding", "gzip")
+	if w.Body.String() != "hello gee" {
+		t.Fatalf("request body should be decompressed, got %q", w.Body.String())
+	}
+}
+
+func TestGzipRequestLimit(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(`{"data":"` + strings.Repeat("a", 1<<20) + `"}`))
+	zw.Close()
+
+	r := New()
+	r.Use(GzipWithConfig(GzipConfig{MaxDecompressedSize: 64 << 10}))
+	r.POST("/bind", func(c *Context) {
+		var body struct{ Data string }
+		if err := c.Bind(&body); err != nil {
+			c.Fail(err.(*HTTPError).Code, err.Error())
+			return
+		}
+		c.String(http.StatusOK, "%d", len(body.Data))
+	})
+	w := performRequest(r, http.MethodPost, "/bind", buf.String(), "Content-Encoding", "gzip", "Content-Type", "application/json")
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized decompressed body should be rejected with 413, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestGzipHijack(t *testing.T) {
+	s := httptest.NewServer(newGzipEngine())
+	defer s.Close()
+	resp, err := http.Get(s.URL + "/hijack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "hijacked" {
+		t.Errorf("Hijack should work behind Gzip, body = %q", body)
+	}
+}
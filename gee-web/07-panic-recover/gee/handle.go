@@ -0,0 +1,150 @@
+package gee
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// StatusCoder 由带有 HTTP 状态码的错误或响应实现。
+// Handle 的处理函数返回的错误实现了它时使用对应的状态码，否则返回 500；
+// 响应实现了它时使用对应的状态码代替 200。
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// HTTPError 是带有状态码的错误。
+type HTTPError struct {
+	XMLName xml.Name `json:"-" xml:"error"`
+	Code    int      `json:"-" xml:"-"`
+	Message string   `json:"message" xml:"message"`
+}
+
+// NewHTTPError 创建一个 HTTPError，message 为空时使用状态码的标准描述。
+func NewHTTPError(code int, message string) *HTTPError {
+	if message == "" {
+		message = http.StatusText(code)
+	}
+	return &HTTPError{Code: code, Message: message}
+}
+
+// Error 实现 error 接口。
+func (e *HTTPError) Error() string {
+	return e.Message
+}
+
+// StatusCode 实现 StatusCoder 接口。
+func (e *HTTPError) StatusCode() int {
+	return e.Code
+}
+
+// Handle 将类型化的处理函数转换为 HandlerFunc。
+// 请求通过 c.Bind 绑定到 Req，返回的 Resp 根据 Accept 头渲染为 JSON 或 XML。
+// 返回错误时，状态码由错误链中的 StatusCoder 决定，默认为 500；DebugMode 以外 5xx 错误不返回错误详情。
+// 需要生成文档时，使用 Describe 将 Req 和 Resp 写入路由的元数据。
+//
+//	r.GET("/users/:id", gee.Handle(getUser))
+func Handle[Req, Resp any](fn func(c *Context, req Req) (Resp, error)) HandlerFunc {
+	return func(c *Context) {
+		var req Req
+		if err := c.Bind(&req); err != nil {
+			c.renderError(err)
+			return
+		}
+		resp, err := fn(c, req)
+		if err != nil {
+			c.renderError(err)
+			return
+		}
+		code := http.StatusOK
+		if sc, ok := any(resp).(StatusCoder); ok {
+			code = sc.StatusCode()
+		}
+		if v := reflect.ValueOf(&resp).Elem(); v.Kind() == reflect.Struct && v.NumField() == 0 ||
+			(v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			if code == http.StatusOK {
+				code = http.StatusNoContent
+			}
+			c.Status(code)
+			return
+		}
+		c.Negotiate(code, resp)
+	}
+}
+
+// Describe 将类型化处理函数的请求和响应类型写入路由的文档元数据，
+// 等价于 route.Accepts(Req 的零值).Returns(http.StatusOK, Resp 的零值)。
+//
+//	gee.Describe(r.GET("/users/:id", gee.Handle(getUser)), getUser).Doc("获取用户", "")
+func Describe[Req, Resp any](route *Route, fn func(c *Context, req Req) (Resp, error)) *Route {
+	var req Req
+	var resp Resp
+	return route.Accepts(req).Returns(http.StatusOK, resp)
+}
+
+// renderError 根据错误的状态码渲染错误响应。
+func (c *Context) renderError(err error) {
+	code := http.StatusInternalServerError
+	var sc StatusCoder
+	if errors.As(err, &sc) {
+		code = sc.StatusCode()
+	}
+	message := err.Error()
+	if code >= http.StatusInternalServerError && !IsDebugging() {
+		message = http.StatusText(code)
+	}
+	// 客户端不接受任何支持的类型时，错误仍以 JSON 返回，而不是变成 406
+	if negotiateType(c.Req.Header.Get("Accept"), negotiableTypes) == "" {
+		c.JSON(code, NewHTTPError(code, message))
+		return
+	}
+	c.Negotiate(code, NewHTTPError(code, message))
+}
+
+// negotiableTypes 是 Negotiate 支持的内容类型，排在前面的优先。
+var negotiableTypes = []string{"application/json", "application/xml", "text/xml"}
+
+// Negotiate 根据请求的 Accept 头选择 JSON 或 XML 渲染 obj，都不接受时返回 406。
+// 参数:
+//   - code: HTTP 状态码。
+//   - obj: 要渲染的对象。
+func (c *Context) Negotiate(code int, obj interface{}) {
+	switch negotiateType(c.Req.Header.Get("Accept"), negotiableTypes) {
+	case "application/json":
+		c.JSON(code, obj)
+	case "application/xml", "text/xml":
+		c.XML(code, obj)
+	default:
+		c.String(http.StatusNotAcceptable, "406 Not Acceptable: supported types are %s\n", strings.Join(negotiableTypes, ", "))
+	}
+}
+
+// negotiateType 从 offers 中选出 Accept 头权重最高的内容类型，Accept 为空时返回 offers[0]，都不接受时返回空字符串。
+// offers[0] 是首选类型，权重相同或只通过通配符被接受时优先返回它；
+// 例如浏览器的 "text/html,application/xml;q=0.9,*/*;q=0.8" 得到 offers[0] 而不是 application/xml。
+func negotiateType(accept string, offers []string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+	q := parseAccept(accept)
+	best, bestQ := "", 0.0
+	for i, offer := range offers {
+		// 精确匹配优先于 "type/*"，"type/*" 优先于 "*/*"
+		w, exact := q[offer]
+		if !exact {
+			var ok bool
+			if w, ok = q[offer[:strings.IndexByte(offer, '/')]+"/*"]; !ok {
+				w = q["*/*"]
+			}
+		}
+		if i == 0 && w > 0 && !exact {
+			return offer
+		}
+		if w > bestQ {
+			best, bestQ = offer, w
+		}
+	}
+	return best
+}
@@ -0,0 +1,124 @@
+package gee
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type updateItemRequest struct {
+	ID      int           `path:"id"`
+	Tags    []string      `query:"tag"`
+	Timeout time.Duration `query:"timeout"`
+	Trace   string        `header:"X-Trace-ID,required"`
+	Name    string        `json:"name"`
+}
+
+type item struct {
+	ID   int      `json:"id" xml:"id"`
+	Name string   `json:"name" xml:"name"`
+	Tags []string `json:"tags" xml:"tag"`
+}
+
+var errItemNotFound = NewHTTPError(http.StatusNotFound, "item not found")
+
+func updateItem(c *Context, req updateItemRequest) (*item, error) {
+	switch req.ID {
+	case 0:
+		return nil, fmt.Errorf("lookup: %w", errItemNotFound)
+	case 500:
+		return nil, errors.New("database is down")
+	}
+	return &item{ID: req.ID, Name: req.Name + "/" + req.Trace + "/" + req.Timeout.String(), Tags: req.Tags}, nil
+}
+
+func newHandleEngine() *Engine {
+	r := New()
+	Describe(r.PUT("/items/:id", Handle(updateItem)), updateItem)
+	r.DELETE("/items/:id", Handle(func(c *Context, req struct{}) (struct{}, error) {
+		return struct{}{}, nil
+	}))
+	r.PATCH("/items/:id", Handle(func(c *Context, req *updateItemRequest) (H, error) {
+		return H{"id": req.ID, "trace": req.Trace}, nil
+	}))
+	return r
+}
+
+func TestHandleBindAndRender(t *testing.T) {
+	r := newHandleEngine()
+
+	w := performRequest(r, "PUT", "/items/7?tag=a&tag=b&timeout=2s", `{"name":"gee"}`,
+		"Content-Type", "application/json", "X-Trace-ID", "t1")
+	if w.Code != http.StatusOK || w.Body.String() != `{"id":7,"name":"gee/t1/2s","tags":["a","b"]}`+"\n" {
+		t.Fatalf("JSON: code = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	form := url.Values{"name": {"form"}}.Encode()
+	w = performRequest(r, "PUT", "/items/8", form,
+		"Content-Type", "application/x-www-form-urlencoded", "X-Trace-ID", "t2", "Accept", "text/html;q=0.9, application/xml")
+	if w.Header().Get("Content-Type") != "application/xml" || !strings.Contains(w.Body.String(), "<name>form/t2/0s</name>") {
+		t.Fatalf("XML: %v %s", w.Header(), w.Body.String())
+	}
+
+	if w := performRequest(r, "PUT", "/items/7", "", "Accept", "text/html"); w.Code != http.StatusBadRequest {
+		t.Errorf("missing required header: code = %d", w.Code)
+	}
+	if w := performRequest(r, "PUT", "/items/x", "", "X-Trace-ID", "t"); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid path parameter: code = %d", w.Code)
+	}
+	if w := performRequest(r, "PUT", "/items/1", "<a/>", "X-Trace-ID", "t", "Content-Type", "text/plain"); w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("unsupported body: code = %d", w.Code)
+	}
+	if w := performRequest(r, "PUT", "/items/1", "", "X-Trace-ID", "t", "Accept", "text/html"); w.Code != http.StatusNotAcceptable {
+		t.Errorf("not acceptable: code = %d", w.Code)
+	}
+	if w := performRequest(r, "DELETE", "/items/1", ""); w.Code != http.StatusNoContent {
+		t.Errorf("empty response: code = %d", w.Code)
+	}
+}
+
+func TestHandlePointerRequestAndBrowserAccept(t *testing.T) {
+	r := newHandleEngine()
+	browser := "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	w := performRequest(r, "PATCH", "/items/9", "", "X-Trace-ID", "t3", "Accept", browser)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" || w.Body.String() != `{"id":9,"trace":"t3"}`+"\n" {
+		t.Errorf("code = %d, headers = %v, body = %s", w.Code, w.Header(), w.Body.String())
+	}
+	if w := performRequest(r, "PUT", "/items/9", "", "X-Trace-ID", "t", "Accept", "application/xml, */*;q=0.1"); w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("JSON accepted through a wildcard should win: %v", w.Header())
+	}
+	if w := performRequest(r, "PUT", "/items/9", "", "X-Trace-ID", "t", "Accept", "application/json;q=0.5, application/xml"); w.Header().Get("Content-Type") != "application/xml" {
+		t.Errorf("explicitly preferred XML should win: %v", w.Header())
+	}
+}
+
+func TestHandleErrors(t *testing.T) {
+	defer SetMode(DebugMode)
+	r := newHandleEngine()
+
+	w := performRequest(r, "PUT", "/items/0", "", "X-Trace-ID", "t")
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "item not found") {
+		t.Errorf("wrapped HTTPError: code = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	SetMode(ReleaseMode)
+	w = performRequest(r, "PUT", "/items/500", "", "X-Trace-ID", "t")
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "database") {
+		t.Errorf("internal error should be hidden in release mode: code = %d, body = %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleRouteMeta(t *testing.T) {
+	r := newHandleEngine()
+	route := r.Routes()[0]
+	if _, ok := route.Meta.Request.(updateItemRequest); !ok {
+		t.Errorf("Request = %T", route.Meta.Request)
+	}
+	if _, ok := route.Meta.Responses[http.StatusOK].(*item); !ok {
+		t.Errorf("Responses = %v", route.Meta.Responses)
+	}
+}
@@ -0,0 +1,85 @@
+package gee
+
+import (
+	"net"
+	"strings"
+)
+
+// hostRouter 是某个主机名模式拥有的路由器。
+type hostRouter struct {
+	pattern string   // 主机名模式，例如 "api.example.com" 或 ":tenant.example.com"
+	parts   []string // 按 "." 拆分后的模式
+	wild    bool     // 模式中是否包含参数
+	router  *router
+}
+
+// Host 返回匹配指定主机名的RouterGroup，每个主机拥有独立的路由树。
+// 主机名中以 ':' 开头的部分是参数，例如 ":tenant.example.com"，可以通过 c.Param("tenant") 获取。
+// 请求的主机名不匹配任何已注册的主机时，使用默认主机（Engine本身）的路由树。
+// Engine上注册的中间件对所有主机生效。
+// 参数:
+//   - pattern: 主机名模式，不包含端口。
+func (engine *Engine) Host(pattern string) *RouterGroup {
+	pattern = strings.TrimSuffix(strings.ToLower(pattern), ".")
+	var hr *hostRouter
+	for _, h := range engine.hosts {
+		if h.pattern == pattern {
+			hr = h
+			break
+		}
+	}
+	if hr == nil {
+		hr = &hostRouter{pattern: pattern, parts: strings.Split(pattern, "."), router: newRouter()}
+		for _, part := range hr.parts {
+			if strings.HasPrefix(part, ":") {
+				hr.wild = true
+			}
+		}
+		engine.hosts = append(engine.hosts, hr)
+	}
+	group := &RouterGroup{parent: engine.RouterGroup, engine: engine, host: hr}
+	engine.groups = append(engine.groups, group)
+	return group
+}
+
+// matchHost 返回与请求主机名匹配的hostRouter及主机参数。
+// 不含参数的模式优先于含参数的模式，同类模式按注册顺序匹配。
+func (engine *Engine) matchHost(host string) (*hostRouter, map[string]string) {
+	if len(engine.hosts) == 0 {
+		return nil, nil
+	}
+	host = normalizeHost(host)
+	for _, hr := range engine.hosts {
+		if !hr.wild && hr.pattern == host {
+			return hr, nil
+		}
+	}
+	parts := strings.Split(host, ".")
+	for _, hr := range engine.hosts {
+		if !hr.wild || len(hr.parts) != len(parts) {
+			continue
+		}
+		params := make(map[string]string)
+		matched := true
+		for i, part := range hr.parts {
+			if strings.HasPrefix(part, ":") && parts[i] != "" {
+				params[part[1:]] = parts[i]
+			} else if part != parts[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return hr, params
+		}
+	}
+	return nil, nil
+}
+
+// normalizeHost 去掉端口和末尾的 "."，并转换为小写。
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
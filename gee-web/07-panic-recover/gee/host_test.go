@@ -0,0 +1,60 @@
+package gee
+
+import (
+	"net/http"
+	"testing"
+)
+
+func newHostEngine() *Engine {
+	r := New()
+	r.GET("/", func(c *Context) { c.String(http.StatusOK, "default") })
+	r.Host("api.example.com").GET("/", func(c *Context) { c.String(http.StatusOK, "api") })
+	r.Host(":tenant.example.com").GET("/users/:id", func(c *Context) {
+		c.String(http.StatusOK, "%s-%s", c.Param("tenant"), c.Param("id"))
+	})
+	return r
+}
+
+func TestHostRouting(t *testing.T) {
+	r := newHostEngine()
+	tests := []struct {
+		host, path, want string
+		code             int
+	}{
+		{"api.example.com", "/", "api", http.StatusOK},
+		{"API.example.com:8080", "/", "api", http.StatusOK},
+		{"acme.example.com", "/users/7", "acme-7", http.StatusOK},
+		{"acme.example.com", "/", "", http.StatusNotFound},
+		{"other.org", "/", "default", http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := performRequest(r, http.MethodGet, "http://"+tt.host+tt.path, "")
+		if w.Code != tt.code {
+			t.Errorf("%s%s: code = %d, want %d", tt.host, tt.path, w.Code, tt.code)
+		}
+		if tt.want != "" && w.Body.String() != tt.want {
+			t.Errorf("%s%s: body = %q, want %q", tt.host, tt.path, w.Body.String(), tt.want)
+		}
+	}
+}
+
+func TestHostMiddleware(t *testing.T) {
+	r := New()
+	var calls []string
+	r.Use(func(c *Context) { calls = append(calls, "global") })
+	admin := r.Host("admin.example.com")
+	admin.Use(func(c *Context) { calls = append(calls, "admin") })
+	admin.GET("/", func(c *Context) { c.String(http.StatusOK, "admin") })
+	r.GET("/", func(c *Context) { c.String(http.StatusOK, "default") })
+
+	performRequest(r, http.MethodGet, "/", "")
+	if len(calls) != 1 || calls[0] != "global" {
+		t.Fatalf("default host calls = %v", calls)
+	}
+
+	calls = nil
+	performRequest(r, http.MethodGet, "http://admin.example.com/", "")
+	if len(calls) != 2 || calls[1] != "admin" {
+		t.Fatalf("admin host calls = %v", calls)
+	}
+}
@@ -0,0 +1,219 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// Config 是幂等中间件的配置。
+type Config struct {
+	Store       Store         // 记录的存储，默认为 NewMemoryStore()
+	Header      string        // 幂等键所在的请求头，默认为 "Idempotency-Key"
+	Methods     []string      // 需要保证幂等的请求方法，默认为 POST 和 PATCH
+	Required    bool          // 为 true 时缺少幂等键的请求返回 400，否则直接处理
+	TTL         time.Duration // 响应的保存时间，默认为 24 小时
+	LockTimeout time.Duration // 处理中记录的有效期，超过后相同键的请求会重新执行，默认为 1 分钟
+	MaxBodySize int64         // 计算指纹时读取的请求体上限，超过时返回 413，默认为 10MB，负数表示不限制
+	// Scope 返回幂等键的命名空间，例如用户 ID，避免不同用户的键互相冲突，默认不区分。
+	Scope func(c *gee.Context) string
+}
+
+// maxKeyLength 是幂等键的最大长度。
+const maxKeyLength = 255
+
+// New 返回幂等中间件。
+// 第一次收到某个幂等键时执行后续的处理函数并保存完整的响应，之后使用相同键的请求直接返回保存的响应，
+// 并带上 Idempotent-Replayed: true 响应头。
+// 相同键的请求还在处理中时返回 409；相同的键用于方法、路径或请求体不同的请求时返回 422。
+// 5xx 响应和 panic 不会被保存，客户端可以使用相同的键重试；请求体超过 MaxBodySize 时返回 413。
+func New(config Config) gee.HandlerFunc {
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	if config.Header == "" {
+		config.Header = "Idempotency-Key"
+	}
+	if config.Methods == nil {
+		config.Methods = []string{http.MethodPost, http.MethodPatch}
+	}
+	if config.TTL <= 0 {
+		config.TTL = 24 * time.Hour
+	}
+	if config.LockTimeout <= 0 {
+		config.LockTimeout = time.Minute
+	}
+	if config.MaxBodySize == 0 {
+		config.MaxBodySize = 10 << 20
+	}
+	methods := make(map[string]bool, len(config.Methods))
+	for _, m := range config.Methods {
+		methods[m] = true
+	}
+
+	return func(c *gee.Context) {
+		if !methods[c.Method] {
+			return
+		}
+		key := c.Req.Header.Get(config.Header)
+		if key == "" {
+			if config.Required {
+				c.Fail(http.StatusBadRequest, "missing "+config.Header+" header")
+			}
+			return
+		}
+		if len(key) > maxKeyLength {
+			c.Fail(http.StatusBadRequest, config.Header+" is too long")
+			return
+		}
+		if config.Scope != nil {
+			key = config.Scope(c) + ":" + key
+		}
+		fingerprint, err := requestHash(c, config.MaxBodySize)
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				c.Fail(http.StatusRequestEntityTooLarge, http.StatusText(http.StatusRequestEntityTooLarge))
+				return
+			}
+			c.Fail(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		token := newToken()
+		record, locked, err := config.Store.Lock(key, fingerprint, token, config.LockTimeout)
+		if err != nil {
+			log.Printf("[Idempotency] lock %q: %v", key, err)
+			c.Fail(http.StatusInternalServerError, "Internal Server Error")
+			return
+		}
+		if !locked {
+			switch {
+			case record.Fingerprint != fingerprint:
+				c.Fail(http.StatusUnprocessableEntity, config.Header+" was used with a different request")
+			case record.Response == nil:
+				c.Writer.Header().Set("Retry-After", "1")
+				c.Fail(http.StatusConflict, "a request with the same "+config.Header+" is in progress")
+			default:
+				replay(c, record.Response)
+			}
+			return
+		}
+
+		// 外层中间件已经设置的响应头（请求 ID、traceparent、Set-Cookie 等）属于当前请求，不保存
+		outer := c.Writer.Header().Clone()
+		rec := &recorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+		completed := false
+		defer func() {
+			c.Writer = rec.ResponseWriter
+			if !completed {
+				// panic 或 5xx，删除记录以便客户端重试
+				if err := config.Store.Unlock(key, token); err != nil {
+					log.Printf("[Idempotency] unlock %q: %v", key, err)
+				}
+			}
+		}()
+		c.Next()
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		resp := &Response{Status: status, Header: changedHeader(outer, rec.Header()), Body: rec.body.Bytes()}
+		if err := config.Store.Complete(key, token, Record{Fingerprint: fingerprint, Response: resp}, config.TTL); err != nil {
+			log.Printf("[Idempotency] complete %q: %v", key, err)
+			return
+		}
+		completed = true
+	}
+}
+
+// requestHash 返回请求的方法、路径和请求体的 SHA-256，并恢复请求体供后续处理函数读取。
+// limit 为请求体的上限，超过时返回 *http.MaxBytesError，负数表示不限制。
+func requestHash(c *gee.Context, limit int64) (string, error) {
+	var body []byte
+	if c.Req.Body != nil {
+		reader := c.Req.Body
+		if limit >= 0 {
+			reader = http.MaxBytesReader(c.Writer, c.Req.Body, limit)
+		}
+		var err error
+		if body, err = io.ReadAll(reader); err != nil {
+			return "", err
+		}
+		c.Req.Body.Close()
+		c.Req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	h := sha256.New()
+	io.WriteString(h, c.Method+" "+c.Req.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// changedHeader 返回 header 中相对 outer 新增或修改的响应头。
+func changedHeader(outer, header http.Header) http.Header {
+	changed := make(http.Header)
+	for name, values := range header {
+		if !slices.Equal(outer[name], values) {
+			changed[name] = slices.Clone(values)
+		}
+	}
+	return changed
+}
+
+// newToken 返回随机的锁令牌。
+func newToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// replay 将保存的响应写给客户端。
+func replay(c *gee.Context, resp *Response) {
+	header := c.Writer.Header()
+	for name, values := range resp.Header {
+		header[name] = values
+	}
+	header.Set("Idempotent-Replayed", "true")
+	c.Status(resp.Status)
+	c.Writer.Write(resp.Body)
+	c.Abort()
+}
+
+// recorder 在写出响应的同时记录状态码和响应体。
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
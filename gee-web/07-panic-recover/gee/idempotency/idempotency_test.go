@@ -0,0 +1,174 @@
+package idempotency
+
+import (
+	"fmt"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"gee-web/gee-web/07-panic-recover/gee/geetest"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pay 创建一个支付请求，key 为空时不带幂等键。
+func pay(client *geetest.Client, key, body string) *geetest.Request {
+	req := client.POST("/payments").WithBody("application/json", strings.NewReader(body))
+	if key != "" {
+		req.WithHeader("Idempotency-Key", key)
+	}
+	return req
+}
+
+func TestReplay(t *testing.T) {
+	var calls atomic.Int32
+	r := gee.New()
+	client := geetest.New(r)
+	r.Use(New(Config{}))
+	r.POST("/payments", func(c *gee.Context) {
+		n := calls.Add(1)
+		c.SetHeader("X-Payment", "p1")
+		c.JSON(http.StatusCreated, gee.H{"call": n})
+	})
+
+	w := pay(client, "k1", `{"amount":100}`).Do()
+	first := w.Body.String()
+	if w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("code = %d, headers = %v", w.Code, w.Header())
+	}
+
+	w = pay(client, "k1", `{"amount":100}`).Do()
+	if w.Code != http.StatusCreated || w.Body.String() != first || w.Header().Get("X-Payment") != "p1" ||
+		w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("replay: code = %d, body = %q, headers = %v", w.Code, w.Body.String(), w.Header())
+	}
+
+	// 相同的键用于不同的请求体
+	pay(client, "k1", `{"amount":200}`).Expect(t).Status(http.StatusUnprocessableEntity)
+
+	pay(client, "", `{"amount":100}`).Do()
+	pay(client, "k2", `{"amount":100}`).Do()
+	if calls.Load() != 3 {
+		t.Errorf("handler called %d times, want 3", calls.Load())
+	}
+}
+
+func TestReplayOwnHeadersOnly(t *testing.T) {
+	var n atomic.Int32
+	r := gee.New()
+	client := geetest.New(r)
+	r.Use(func(c *gee.Context) {
+		id := fmt.Sprint(n.Add(1))
+		c.SetHeader("X-Request-ID", id)
+		http.SetCookie(c.Writer, &http.Cookie{Name: "visit", Value: id})
+	}, New(Config{}))
+	r.POST("/payments", func(c *gee.Context) {
+		c.SetHeader("X-Payment", "p1")
+		c.String(http.StatusCreated, "ok")
+	})
+
+	pay(client, "k1", "a").Do()
+	w := pay(client, "k1", "a").Do()
+	if w.Header().Get("Idempotent-Replayed") != "true" || w.Header().Get("X-Payment") != "p1" {
+		t.Fatalf("replay: headers = %v", w.Header())
+	}
+	if w.Header().Get("X-Request-ID") != "2" || len(w.Header().Values("Set-Cookie")) != 1 || w.Result().Cookies()[0].Value != "2" {
+		t.Errorf("headers of the first request should not be replayed: %v", w.Header())
+	}
+}
+
+func TestInProgressAndFailure(t *testing.T) {
+	var calls atomic.Int32
+	store := NewMemoryStore()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r := gee.New()
+	client := geetest.New(r)
+	r.Use(gee.Recovery(), New(Config{Store: store}))
+	r.POST("/payments", func(c *gee.Context) {
+		switch calls.Add(1) {
+		case 1:
+			close(started)
+			<-release
+			c.String(http.StatusOK, "ok")
+		case 2:
+			c.String(http.StatusBadGateway, "upstream error")
+		case 3:
+			panic("boom")
+		default:
+			c.String(http.StatusOK, "ok")
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		pay(client, "k1", "a").Do()
+		close(done)
+	}()
+	<-started
+	w := pay(client, "k1", "a").Do()
+	if w.Code != http.StatusConflict || w.Header().Get("Retry-After") == "" {
+		t.Errorf("in progress: code = %d, headers = %v", w.Code, w.Header())
+	}
+	close(release)
+	<-done
+
+	// 5xx 和 panic 不保存，相同的键可以重试
+	for _, code := range []int{http.StatusBadGateway, http.StatusInternalServerError, http.StatusOK} {
+		pay(client, "k2", "b").Expect(t).Status(code)
+	}
+	if calls.Load() != 4 || store.Len() != 2 {
+		t.Errorf("calls = %d, stored = %d", calls.Load(), store.Len())
+	}
+}
+
+func TestMemoryStoreExpire(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	if _, locked, _ := store.Lock("k", "f", "t", time.Minute); !locked {
+		t.Fatal("first Lock should succeed")
+	}
+	store.Complete("k", "t", Record{Fingerprint: "f", Response: &Response{Status: http.StatusOK}}, time.Hour)
+	if record, locked, _ := store.Lock("k", "f", "t", time.Minute); locked || record.Response.Status != http.StatusOK {
+		t.Fatalf("completed record should be returned, locked = %v", locked)
+	}
+	now = now.Add(time.Hour)
+	if _, locked, _ := store.Lock("k", "f", "t", time.Minute); !locked {
+		t.Fatal("expired record should be replaced")
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	r := gee.New()
+	client := geetest.New(r)
+	r.Use(New(Config{MaxBodySize: 4}))
+	r.POST("/payments", func(c *gee.Context) { c.String(http.StatusOK, "ok") })
+
+	pay(client, "k1", "12345").Expect(t).Status(http.StatusRequestEntityTooLarge)
+	pay(client, "k1", "1234").Expect(t).Status(http.StatusOK)
+}
+
+func TestUnlockAfterLockTimeout(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	store.Lock("k", "f", "t1", time.Minute)
+	now = now.Add(2 * time.Minute)
+	if _, locked, _ := store.Lock("k", "f", "t2", time.Minute); !locked {
+		t.Fatal("expired lock should be taken by the next request")
+	}
+
+	// 第一个请求超时后才结束，不能覆盖或删除第二个请求的锁
+	if err := store.Complete("k", "t1", Record{Fingerprint: "f", Response: &Response{}}, time.Hour); err != ErrLockLost {
+		t.Errorf("Complete with a stale token: err = %v", err)
+	}
+	store.Unlock("k", "t1")
+	if record, locked, _ := store.Lock("k", "f", "t3", time.Minute); locked || record.Token != "t2" {
+		t.Fatalf("lock of another request should be kept, locked = %v", locked)
+	}
+	store.Unlock("k", "t2")
+	if store.Len() != 0 {
+		t.Errorf("stored = %d, want 0", store.Len())
+	}
+}
@@ -0,0 +1,138 @@
+package idempotency
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrLockLost 表示处理中记录的锁已过期并被其他请求重新锁定，由 Store.Complete 返回。
+var ErrLockLost = errors.New("idempotency: lock was lost")
+
+// Response 是保存的响应。
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Record 是 Store 中一个幂等键的记录。
+type Record struct {
+	Fingerprint string    // 请求的方法、路径和请求体的 SHA-256，用于发现重复使用的键
+	Response    *Response // 请求处理完成后保存的响应，为 nil 表示请求还在处理中
+	Token       string    // 处理中记录的锁令牌，Unlock 只删除令牌相同的记录
+}
+
+// Store 保存幂等键的记录，实现需要保证并发安全，多个实例共享同一个 Store 时需要保证 Lock 的原子性。
+type Store interface {
+	// Lock 在 key 不存在时保存一条令牌为 token 的处理中记录并返回 true，记录在 ttl 后过期，防止进程退出后键一直被占用；
+	// key 已存在时返回现有的记录和 false。
+	Lock(key, fingerprint, token string, ttl time.Duration) (record *Record, locked bool, err error)
+	// Complete 在 key 的记录仍在处理中且令牌为 token 时保存处理完成后的记录，ttl 为记录的保存时间；
+	// 令牌不同或记录已不存在时不做修改并返回 ErrLockLost，避免覆盖其他请求的锁或记录。
+	Complete(key, token string, record Record, ttl time.Duration) error
+	// Unlock 在 key 的记录仍在处理中且令牌为 token 时删除记录，之后使用相同键的请求会重新执行。
+	// 记录已过期并被其他请求重新锁定时不做任何事，避免删除其他请求的锁。
+	Unlock(key, token string) error
+}
+
+// memoryItem 是 MemoryStore 中保存的一条记录。
+type memoryItem struct {
+	record  Record
+	expires time.Time
+}
+
+// MemoryStore 是 Store 的内存实现，只适用于单个实例，数据在进程重启后丢失。
+// 过期记录在读取时删除，同时每隔 gcInterval 在写入时清理一次。
+type MemoryStore struct {
+	mu         sync.Mutex
+	items      map[string]*memoryItem
+	gcInterval time.Duration
+	lastGC     time.Time
+	now        func() time.Time // 便于测试替换
+}
+
+// NewMemoryStore 创建一个 MemoryStore。
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		items:      make(map[string]*memoryItem),
+		gcInterval: time.Minute,
+		lastGC:     time.Now(),
+		now:        time.Now,
+	}
+}
+
+// Lock 实现 Store 接口。
+func (m *MemoryStore) Lock(key, fingerprint, token string, ttl time.Duration) (*Record, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	if now.Sub(m.lastGC) >= m.gcInterval {
+		m.gc(now)
+	}
+	if item, ok := m.items[key]; ok && now.Before(item.expires) {
+		record := item.record
+		record.Response = copyResponse(record.Response)
+		return &record, false, nil
+	}
+	m.items[key] = &memoryItem{record: Record{Fingerprint: fingerprint, Token: token}, expires: now.Add(ttl)}
+	return nil, true, nil
+}
+
+// Complete 实现 Store 接口。
+func (m *MemoryStore) Complete(key, token string, record Record, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.locked(key, token) {
+		return ErrLockLost
+	}
+	record.Response = copyResponse(record.Response)
+	m.items[key] = &memoryItem{record: record, expires: m.now().Add(ttl)}
+	return nil
+}
+
+// Unlock 实现 Store 接口。
+func (m *MemoryStore) Unlock(key, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.locked(key, token) {
+		delete(m.items, key)
+	}
+	return nil
+}
+
+// locked 返回 key 的记录是否仍在处理中且令牌为 token，调用方需持有锁。
+func (m *MemoryStore) locked(key, token string) bool {
+	item, ok := m.items[key]
+	return ok && item.record.Response == nil && item.record.Token == token
+}
+
+// Len 返回当前保存的记录数量（包括尚未清理的过期记录）。
+func (m *MemoryStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.items)
+}
+
+// gc 删除所有过期的记录，调用方需持有锁。
+func (m *MemoryStore) gc(now time.Time) {
+	for key, item := range m.items {
+		if !now.Before(item.expires) {
+			delete(m.items, key)
+		}
+	}
+	m.lastGC = now
+}
+
+// copyResponse 深拷贝响应，避免存储与请求之间共享同一个 Header 或 Body。
+func copyResponse(resp *Response) *Response {
+	if resp == nil {
+		return nil
+	}
+	return &Response{
+		Status: resp.Status,
+		Header: resp.Header.Clone(),
+		Body:   append([]byte(nil), resp.Body...),
+	}
+}
@@ -0,0 +1,185 @@
+package jwt
+
+import (
+	"errors"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ClaimsKey 是校验通过的 claims 在 gee.Context 中保存时使用的键。
+const ClaimsKey = "gee-web/jwt"
+
+var (
+	// ErrMissingToken 表示请求中没有携带 token。
+	ErrMissingToken = errors.New("jwt: missing token")
+	// ErrExpired 表示 token 已过期（exp）。
+	ErrExpired = errors.New("jwt: token is expired")
+	// ErrNotValidYet 表示 token 尚未生效（nbf）。
+	ErrNotValidYet = errors.New("jwt: token is not valid yet")
+	// ErrIssuer 表示签发者（iss）不匹配。
+	ErrIssuer = errors.New("jwt: invalid issuer")
+	// ErrAudience 表示受众（aud）不匹配。
+	ErrAudience = errors.New("jwt: invalid audience")
+	// ErrInvalidClaim 表示时间声明（exp、nbf）存在但不是数字。
+	ErrInvalidClaim = errors.New("jwt: invalid time claim")
+)
+
+// Claims 是 token 中的声明。
+type Claims map[string]interface{}
+
+// Subject 返回 sub 声明。
+func (c Claims) Subject() string {
+	s, _ := c["sub"].(string)
+	return s
+}
+
+// Issuer 返回 iss 声明。
+func (c Claims) Issuer() string {
+	s, _ := c["iss"].(string)
+	return s
+}
+
+// Audience 返回 aud 声明，aud 可以是字符串或字符串数组。
+func (c Claims) Audience() []string {
+	switch v := c["aud"].(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		aud := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				aud = append(aud, s)
+			}
+		}
+		return aud
+	}
+	return nil
+}
+
+// numericDate 返回以 Unix 秒表示的时间声明，例如 exp、nbf、iat。
+// 声明不存在时 ok 为 false；存在但不是数字时返回 ErrInvalidClaim，不能当作没有该声明。
+func (c Claims) numericDate(name string) (t time.Time, ok bool, err error) {
+	v, present := c[name]
+	if !present {
+		return time.Time{}, false, nil
+	}
+	switch v := v.(type) {
+	case float64:
+		return time.Unix(int64(v), 0), true, nil
+	case int64:
+		return time.Unix(v, 0), true, nil
+	case int:
+		return time.Unix(int64(v), 0), true, nil
+	}
+	return time.Time{}, false, ErrInvalidClaim
+}
+
+// Config 是 JWT 中间件的配置。
+type Config struct {
+	// Keys 是用于校验的密钥。有多个密钥时按 token 头部的 kid 选择，便于轮换密钥。
+	Keys []Key
+	// KeyFunc 动态查找密钥，例如从 JWKS 中加载，在 Keys 中找不到时使用。
+	KeyFunc func(kid, alg string) (Key, bool)
+	// Issuer 非空时要求 iss 与之相等。
+	Issuer string
+	// Audience 非空时要求 aud 包含该值。
+	Audience string
+	// Leeway 是校验 exp 和 nbf 时允许的时钟误差。
+	Leeway time.Duration
+}
+
+// New 返回 JWT 认证中间件。
+// token 从 "Authorization: Bearer <token>" 中读取；校验通过后 claims 保存在 Context 中，可以通过 GetClaims 获取。
+// 校验失败时返回 401。
+func New(config Config) gee.HandlerFunc {
+	return func(c *gee.Context) {
+		claims, err := config.Parse(bearerToken(c.Req))
+		if err != nil {
+			c.SetHeader("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.Fail(http.StatusUnauthorized, err.Error())
+			return
+		}
+		c.Set(ClaimsKey, claims)
+	}
+}
+
+// GetClaims 返回 JWT 中间件保存的 claims，未经过中间件时返回 nil。
+func GetClaims(c *gee.Context) Claims {
+	v, _ := c.Get(ClaimsKey)
+	claims, _ := v.(Claims)
+	return claims
+}
+
+// Parse 校验 token 的签名和 exp、nbf、iss、aud 声明，返回其中的 claims。
+func (config *Config) Parse(token string) (Claims, error) {
+	if token == "" {
+		return nil, ErrMissingToken
+	}
+	claims, err := parse(token, config.lookup)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	exp, ok, err := claims.numericDate("exp")
+	if err != nil {
+		return nil, err
+	}
+	if ok && !now.Before(exp.Add(config.Leeway)) {
+		return nil, ErrExpired
+	}
+	nbf, ok, err := claims.numericDate("nbf")
+	if err != nil {
+		return nil, err
+	}
+	if ok && now.Add(config.Leeway).Before(nbf) {
+		return nil, ErrNotValidYet
+	}
+	if config.Issuer != "" && claims.Issuer() != config.Issuer {
+		return nil, ErrIssuer
+	}
+	if config.Audience != "" && !contains(claims.Audience(), config.Audience) {
+		return nil, ErrAudience
+	}
+	return claims, nil
+}
+
+// lookup 根据 kid 和 alg 查找校验密钥。
+// token 没有 kid 时，使用 Keys 中唯一一个算法匹配的密钥。
+func (config *Config) lookup(kid, alg string) (Key, bool) {
+	var found []Key
+	for _, key := range config.Keys {
+		if key.Algorithm == alg && (kid == "" || key.ID == kid) {
+			found = append(found, key)
+		}
+	}
+	if len(found) == 1 {
+		return found[0], true
+	}
+	if config.KeyFunc != nil {
+		return config.KeyFunc(kid, alg)
+	}
+	return Key{}, false
+}
+
+// bearerToken 从 Authorization 头中读取 Bearer token。
+func bearerToken(req *http.Request) string {
+	auth := req.Header.Get("Authorization")
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+// contains 判断 list 中是否包含 s。
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
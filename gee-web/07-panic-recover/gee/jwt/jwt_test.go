@@ -0,0 +1,106 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSignAndParse(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	keys := []Key{
+		{ID: "hs-old", Algorithm: HS256, Secret: []byte("old secret")},
+		{ID: "hs-new", Algorithm: HS256, Secret: []byte("new secret")},
+		{ID: "rs", Algorithm: RS256, PublicKey: &rsaKey.PublicKey, PrivateKey: rsaKey},
+		{ID: "es", Algorithm: ES256, PublicKey: &ecKey.PublicKey, PrivateKey: ecKey},
+	}
+	config := &Config{Keys: keys}
+	for _, key := range keys {
+		token, err := Sign(Claims{"sub": key.ID}, key)
+		if err != nil {
+			t.Fatalf("%s: %v", key.ID, err)
+		}
+		claims, err := config.Parse(token)
+		if err != nil || claims.Subject() != key.ID {
+			t.Fatalf("%s: parse failed, %v", key.ID, err)
+		}
+	}
+
+	// kid 指向一个密钥，却使用另一个密钥签名
+	forged, _ := Sign(Claims{"sub": "x"}, Key{ID: "hs-new", Algorithm: HS256, Secret: []byte("old secret")})
+	if _, err := config.Parse(forged); err != ErrSignature {
+		t.Fatalf("expect ErrSignature, got %v", err)
+	}
+	// 使用 HS256 伪造 RS256 密钥的 kid
+	confused, _ := Sign(Claims{"sub": "x"}, Key{ID: "rs", Algorithm: HS256, Secret: []byte("x")})
+	if _, err := config.Parse(confused); err != ErrUnknownKey {
+		t.Fatalf("expect ErrUnknownKey, got %v", err)
+	}
+
+	// ES256 只接受 P-256 曲线的密钥
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if _, err := Sign(Claims{"sub": "x"}, Key{Algorithm: ES256, PrivateKey: p384}); err == nil {
+		t.Fatal("signing ES256 with a P-384 key should fail")
+	}
+	token, _ := Sign(Claims{"sub": "x"}, Key{Algorithm: ES256, PrivateKey: ecKey})
+	p384Config := &Config{Keys: []Key{{Algorithm: ES256, PublicKey: &p384.PublicKey}}}
+	if _, err := p384Config.Parse(token); err != ErrSignature {
+		t.Fatalf("expect ErrSignature for a P-384 public key, got %v", err)
+	}
+}
+
+func TestClaimsValidation(t *testing.T) {
+	key := Key{Algorithm: HS256, Secret: []byte("secret")}
+	config := &Config{Keys: []Key{key}, Issuer: "gee", Audience: "api", Leeway: time.Second}
+	now := time.Now().Unix()
+	cases := []struct {
+		claims Claims
+		err    error
+	}{
+		{Claims{"iss": "gee", "aud": "api", "exp": now + 60}, nil},
+		{Claims{"iss": "gee", "aud": []string{"web", "api"}, "nbf": now}, nil},
+		{Claims{"iss": "gee", "aud": "api", "exp": now - 60}, ErrExpired},
+		{Claims{"iss": "gee", "aud": "api", "nbf": now + 60}, ErrNotValidYet},
+		{Claims{"iss": "other", "aud": "api"}, ErrIssuer},
+		{Claims{"iss": "gee", "aud": "web"}, ErrAudience},
+		{Claims{"iss": "gee", "aud": "api", "exp": "9999999999"}, ErrInvalidClaim},
+		{Claims{"iss": "gee", "aud": "api", "nbf": nil}, ErrInvalidClaim},
+	}
+	for i, tc := range cases {
+		token, _ := Sign(tc.claims, key)
+		if _, err := config.Parse(token); err != tc.err {
+			t.Fatalf("case %d: expect %v, got %v", i, tc.err, err)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	key := Key{ID: "k1", Algorithm: HS256, Secret: []byte("secret")}
+	r := gee.New()
+	r.Use(New(Config{Keys: []Key{key}}))
+	r.GET("/me", func(c *gee.Context) {
+		c.String(http.StatusOK, "%s", GetClaims(c).Subject())
+	})
+
+	token, _ := Sign(Claims{"sub": "geektutu"}, key)
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "geektutu" {
+		t.Fatalf("expect geektutu, got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/me", nil))
+	if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("missing token should be rejected, got %d", w.Code)
+	}
+}
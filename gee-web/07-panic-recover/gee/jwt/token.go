@@ -0,0 +1,158 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// 支持的签名算法。
+const (
+	HS256 = "HS256" // HMAC-SHA256
+	RS256 = "RS256" // RSASSA-PKCS1-v1_5 + SHA256
+	ES256 = "ES256" // ECDSA P-256 + SHA256
+)
+
+var (
+	// ErrMalformed 表示 token 格式错误。
+	ErrMalformed = errors.New("jwt: malformed token")
+	// ErrUnknownKey 表示找不到与 token 的 kid 和 alg 匹配的密钥。
+	ErrUnknownKey = errors.New("jwt: unknown signing key")
+	// ErrSignature 表示签名校验失败。
+	ErrSignature = errors.New("jwt: invalid signature")
+)
+
+// Key 是签名或校验使用的密钥。
+// HS256 使用 Secret；RS256 和 ES256 校验时使用 PublicKey，签名时使用 PrivateKey。
+type Key struct {
+	ID         string           // 密钥 ID，对应 token 头部的 kid，用于密钥轮换
+	Algorithm  string           // 签名算法：HS256、RS256 或 ES256
+	Secret     []byte           // HS256 的共享密钥
+	PublicKey  crypto.PublicKey // *rsa.PublicKey 或 *ecdsa.PublicKey
+	PrivateKey crypto.Signer    // *rsa.PrivateKey 或 *ecdsa.PrivateKey，只在签名时需要
+}
+
+// header 是 token 的头部。
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// Sign 使用 key 对 claims 签名，返回紧凑格式的 token。
+func Sign(claims Claims, key Key) (string, error) {
+	h, err := json.Marshal(header{Alg: key.Algorithm, Kid: key.ID, Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := encode(h) + "." + encode(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var sig []byte
+	switch key.Algorithm {
+	case HS256:
+		mac := hmac.New(sha256.New, key.Secret)
+		mac.Write([]byte(signingInput))
+		sig = mac.Sum(nil)
+	case RS256:
+		if sig, err = key.PrivateKey.Sign(rand.Reader, digest[:], crypto.SHA256); err != nil {
+			return "", err
+		}
+	case ES256:
+		priv, ok := key.PrivateKey.(*ecdsa.PrivateKey)
+		if !ok || priv.Curve != elliptic.P256() {
+			return "", fmt.Errorf("jwt: ES256 requires a P-256 *ecdsa.PrivateKey")
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, priv, digest[:])
+		if err != nil {
+			return "", err
+		}
+		// JWS 使用定长的 r||s，而不是 ASN.1 编码
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	default:
+		return "", fmt.Errorf("jwt: unsupported algorithm %q", key.Algorithm)
+	}
+	return signingInput + "." + encode(sig), nil
+}
+
+// parse 校验 token 的签名并返回其中的 claims，lookup 根据头部的 kid 和 alg 查找密钥。
+func parse(token string, lookup func(kid, alg string) (Key, bool)) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	var h header
+	if err := decodeJSON(parts[0], &h); err != nil {
+		return nil, ErrMalformed
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	key, ok := lookup(h.Kid, h.Alg)
+	// 密钥的算法必须与头部一致，防止 alg=none 或 RS256/HS256 混淆攻击
+	if !ok || key.Algorithm != h.Alg {
+		return nil, ErrUnknownKey
+	}
+	if !verify(parts[0]+"."+parts[1], sig, key) {
+		return nil, ErrSignature
+	}
+	var claims Claims
+	if err = decodeJSON(parts[1], &claims); err != nil {
+		return nil, ErrMalformed
+	}
+	return claims, nil
+}
+
+// verify 校验签名。
+func verify(signingInput string, sig []byte, key Key) bool {
+	digest := sha256.Sum256([]byte(signingInput))
+	switch key.Algorithm {
+	case HS256:
+		mac := hmac.New(sha256.New, key.Secret)
+		mac.Write([]byte(signingInput))
+		return len(key.Secret) > 0 && hmac.Equal(sig, mac.Sum(nil))
+	case RS256:
+		pub, ok := key.PublicKey.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil
+	case ES256:
+		pub, ok := key.PublicKey.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != elliptic.P256() || len(sig) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(pub, digest[:], r, s)
+	}
+	return false
+}
+
+// encode 使用不带填充的 base64url 编码。
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeJSON 解码 base64url 编码的 JSON。
+func decodeJSON(s string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -5,14 +5,28 @@ import (
 	"time"
 )
 
-// Logger 日志中间件
+// 请求 ID 和链路追踪 ID 在 Context 中保存时使用的键，由 tracing 中间件写入，Logger 会在日志中输出它们。
+const (
+	RequestIDKey = "gee-web/request-id"
+	TraceIDKey   = "gee-web/trace-id"
+)
+
+// Logger 日志中间件，TestMode 下不输出日志
 func Logger() HandlerFunc {
 	return func(c *Context) {
 		// Start timer
 		t := time.Now()
 		// Process request
 		c.Next()
+		if Mode() == TestMode {
+			return
+		}
 		// Calculate resolution time
+		if requestID := c.GetString(RequestIDKey); requestID != "" {
+			log.Printf("[%d] %s in %v request_id=%s trace_id=%s",
+				c.StatusCode, c.Req.RequestURI, time.Since(t), requestID, c.GetString(TraceIDKey))
+			return
+		}
 		log.Printf("[%d] %s in %v", c.StatusCode, c.Req.RequestURI, time.Since(t))
 	}
 }
@@ -0,0 +1,189 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// labelSep 用于拼接标签值作为 map 的键，不会出现在合法的 UTF-8 文本中。
+const labelSep = "\xff"
+
+// counterVec 是一组按标签区分的计数器。
+type counterVec struct {
+	name, help string
+	labels     []string
+	mu         sync.Mutex
+	values     map[string]float64
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	return &counterVec{name: name, help: help, labels: labels, values: make(map[string]float64)}
+}
+
+// inc 将标签值对应的计数器加一。
+func (v *counterVec) inc(labelValues ...string) {
+	key := strings.Join(labelValues, labelSep)
+	v.mu.Lock()
+	v.values[key]++
+	v.mu.Unlock()
+}
+
+// write 以文本格式输出计数器。
+func (v *counterVec) write(w io.Writer) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", v.name, escapeHelp(v.help), v.name); err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(v.values) {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", v.name, formatLabels(v.labels, key, "", ""), formatFloat(v.values[key])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gauge 是一个可增可减的数值。
+type gauge struct {
+	name, help string
+	mu         sync.Mutex
+	value      float64
+}
+
+// add 将数值加上 delta。
+func (g *gauge) add(delta float64) {
+	g.mu.Lock()
+	g.value += delta
+	g.mu.Unlock()
+}
+
+// write 以文本格式输出数值。
+func (g *gauge) write(w io.Writer) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n",
+		g.name, escapeHelp(g.help), g.name, g.name, formatFloat(g.value))
+	return err
+}
+
+// histogram 是单个标签组合的直方图数据。
+type histogram struct {
+	counts []uint64 // 每个桶的计数（非累积）
+	sum    float64
+	count  uint64
+}
+
+// histogramVec 是一组按标签区分的直方图。
+type histogramVec struct {
+	name, help string
+	labels     []string
+	buckets    []float64 // 升序排列的桶上界，不包括 +Inf
+	mu         sync.Mutex
+	values     map[string]*histogram
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &histogramVec{name: name, help: help, labels: labels, buckets: buckets, values: make(map[string]*histogram)}
+}
+
+// observe 记录一次观测值。
+func (v *histogramVec) observe(value float64, labelValues ...string) {
+	key := strings.Join(labelValues, labelSep)
+	i := sort.SearchFloat64s(v.buckets, value)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	h, ok := v.values[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(v.buckets)+1)}
+		v.values[key] = h
+	}
+	h.counts[i]++
+	h.sum += value
+	h.count++
+}
+
+// write 以文本格式输出直方图，桶计数是累积的，最后一个桶为 +Inf。
+func (v *histogramVec) write(w io.Writer) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", v.name, escapeHelp(v.help), v.name); err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(v.values) {
+		h := v.values[key]
+		var cumulative uint64
+		for i, count := range h.counts {
+			cumulative += count
+			le := "+Inf"
+			if i < len(v.buckets) {
+				le = formatFloat(v.buckets[i])
+			}
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", v.name, formatLabels(v.labels, key, "le", le), cumulative); err != nil {
+				return err
+			}
+		}
+		labels := formatLabels(v.labels, key, "", "")
+		if _, err := fmt.Fprintf(w, "%s_sum%s %s\n%s_count%s %d\n", v.name, labels, formatFloat(h.sum), v.name, labels, h.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedKeys 返回排序后的 map 键，保证输出稳定。
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatLabels 将标签名和拼接后的标签值格式化为 {a="1",b="2"}，extraName 非空时追加一个额外标签。
+func formatLabels(names []string, key, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	var pairs []string
+	if len(names) > 0 {
+		values := strings.Split(key, labelSep)
+		for i, name := range names {
+			pairs = append(pairs, name+`="`+escapeLabel(values[i])+`"`)
+		}
+	}
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+extraValue+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// escapeLabel 转义标签值中的反斜杠、双引号和换行符。
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// escapeHelp 转义 HELP 文本中的反斜杠和换行符。
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+// formatFloat 按 Prometheus 文本格式输出浮点数。
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
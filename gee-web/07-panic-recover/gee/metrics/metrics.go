@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"bytes"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// unmatchedRoute 是没有匹配到任何路由的请求使用的 route 标签值。
+const unmatchedRoute = "unmatched"
+
+// otherMethod 是非标准请求方法使用的 method 标签值。
+const otherMethod = "OTHER"
+
+// standardMethods 是作为 method 标签值保留的标准请求方法。
+var standardMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true, http.MethodPatch: true,
+	http.MethodDelete: true, http.MethodConnect: true, http.MethodOptions: true, http.MethodTrace: true,
+}
+
+var (
+	// DefaultDurationBuckets 是请求耗时直方图的默认桶（秒）。
+	DefaultDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// DefaultSizeBuckets 是请求和响应大小直方图的默认桶（字节）。
+	DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+// Config 是指标中间件的配置。
+type Config struct {
+	Namespace       string    // 指标名称前缀，默认为 "gee"
+	DurationBuckets []float64 // 请求耗时直方图的桶，默认为 DefaultDurationBuckets
+	SizeBuckets     []float64 // 请求和响应大小直方图的桶，默认为 DefaultSizeBuckets
+}
+
+// Metrics 收集 HTTP 请求的指标，并以 Prometheus 文本格式输出。
+type Metrics struct {
+	requests     *counterVec
+	inFlight     *gauge
+	duration     *histogramVec
+	requestSize  *histogramVec
+	responseSize *histogramVec
+}
+
+// New 创建一个 Metrics。
+func New(config Config) *Metrics {
+	if config.Namespace == "" {
+		config.Namespace = "gee"
+	}
+	if config.DurationBuckets == nil {
+		config.DurationBuckets = DefaultDurationBuckets
+	}
+	if config.SizeBuckets == nil {
+		config.SizeBuckets = DefaultSizeBuckets
+	}
+	ns := config.Namespace + "_http_"
+	return &Metrics{
+		requests: newCounterVec(ns+"requests_total",
+			"Total number of HTTP requests.", "method", "route", "status"),
+		inFlight: &gauge{name: ns + "requests_in_flight",
+			help: "Number of HTTP requests currently being served."},
+		duration: newHistogramVec(ns+"request_duration_seconds",
+			"HTTP request latency in seconds.", config.DurationBuckets, "method", "route"),
+		requestSize: newHistogramVec(ns+"request_size_bytes",
+			"HTTP request body size in bytes.", config.SizeBuckets, "method", "route"),
+		responseSize: newHistogramVec(ns+"response_size_bytes",
+			"HTTP response body size in bytes.", config.SizeBuckets, "method", "route"),
+	}
+}
+
+// Middleware 返回记录指标的中间件。
+// route 标签使用匹配到的路由模式（c.FullPath()），而不是原始路径，非标准的请求方法记为 "OTHER"，以限制标签的基数。
+func (m *Metrics) Middleware() gee.HandlerFunc {
+	return func(c *gee.Context) {
+		start := time.Now()
+		m.inFlight.add(1)
+		w := &countingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		defer func() {
+			c.Writer = w.ResponseWriter
+			m.inFlight.add(-1)
+
+			route := c.FullPath()
+			if route == "" {
+				route = unmatchedRoute
+			}
+			status := c.StatusCode
+			if status == 0 {
+				status = http.StatusOK
+			}
+			method := c.Method
+			if !standardMethods[method] {
+				method = otherMethod
+			}
+			requestSize := c.Req.ContentLength
+			if requestSize < 0 {
+				requestSize = 0
+			}
+			m.requests.inc(method, route, strconv.Itoa(status))
+			m.duration.observe(time.Since(start).Seconds(), method, route)
+			m.requestSize.observe(float64(requestSize), method, route)
+			m.responseSize.observe(float64(w.size), method, route)
+		}()
+		c.Next()
+	}
+}
+
+// WriteText 以 Prometheus 文本格式（0.0.4）输出所有指标。
+func (m *Metrics) WriteText(w io.Writer) error {
+	for _, write := range []func(io.Writer) error{
+		m.requests.write, m.inFlight.write, m.duration.write, m.requestSize.write, m.responseSize.write,
+	} {
+		if err := write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler 返回输出指标的处理函数，通常注册为 GET /metrics。
+func (m *Metrics) Handler() gee.HandlerFunc {
+	return func(c *gee.Context) {
+		var buf bytes.Buffer
+		if err := m.WriteText(&buf); err != nil {
+			c.Fail(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.SetHeader("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.Data(http.StatusOK, buf.Bytes())
+	}
+}
+
+// countingWriter 统计写出的响应体字节数。
+type countingWriter struct {
+	http.ResponseWriter
+	size int
+}
+
+// Write 实现 http.ResponseWriter 接口。
+func (w *countingWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// Flush 实现 http.Flusher 接口。
+func (w *countingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap 返回被包装的 http.ResponseWriter，使 http.ResponseController 可以使用 Hijack 等方法。
+func (w *countingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
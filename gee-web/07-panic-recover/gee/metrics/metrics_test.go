@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"gee-web/gee-web/07-panic-recover/gee"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	m := New(Config{DurationBuckets: []float64{0.5, 1}, SizeBuckets: []float64{10}})
+	r := gee.New()
+	r.Use(m.Middleware())
+	r.GET("/hello/:name", func(c *gee.Context) {
+		c.String(http.StatusOK, "hello %s", c.Param("name"))
+	})
+	r.GET("/metrics", m.Handler())
+
+	for _, path := range []string{"/hello/a", "/hello/b", "/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	for _, method := range []string{"FOO", "BAR"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/missing", nil))
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+
+	for _, want := range []string{
+		"# TYPE gee_http_requests_total counter\n",
+		`gee_http_requests_total{method="GET",route="/hello/:name",status="200"} 2` + "\n",
+		`gee_http_requests_total{method="GET",route="unmatched",status="404"} 1` + "\n",
+		`gee_http_requests_total{method="OTHER",route="unmatched",status="404"} 2` + "\n",
+		"gee_http_requests_in_flight 1\n",
+		`gee_http_request_duration_seconds_bucket{method="GET",route="/hello/:name",le="+Inf"} 2` + "\n",
+		`gee_http_request_duration_seconds_count{method="GET",route="/hello/:name"} 2` + "\n",
+		`gee_http_response_size_bytes_bucket{method="GET",route="/hello/:name",le="10"} 2` + "\n",
+		`gee_http_response_size_bytes_sum{method="GET",route="/hello/:name"} 14` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("metrics should contain %q, got:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "/hello/a") || strings.Contains(body, "FOO") {
+		t.Fatal("raw path and non-standard methods should not be used as labels")
+	}
+}
+
+func TestHijack(t *testing.T) {
+	m := New(Config{})
+	r := gee.New()
+	r.Use(m.Middleware())
+	r.GET("/hijack", func(c *gee.Context) {
+		conn, rw, err := http.NewResponseController(c.Writer).Hijack()
+		if err != nil {
+			c.String(http.StatusInternalServerError, "%v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		rw.Flush()
+	})
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	resp, err := http.Get(s.URL + "/hijack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "hijacked" {
+		t.Errorf("Hijack should work behind Metrics, body = %q", body)
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if got := escapeLabel("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Fatalf("unexpected escape %s", got)
+	}
+}
@@ -0,0 +1,96 @@
+package gee
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"runtime"
+	"sync/atomic"
+)
+
+// EnvGeeMode 是用于设置运行模式的环境变量名。
+const EnvGeeMode = "GEE_MODE"
+
+// gee 的运行模式。
+const (
+	// DebugMode 打印路由表和配置警告，模板每次渲染前重新加载，Recovery 输出完整堆栈。
+	DebugMode = "debug"
+	// ReleaseMode 不打印调试信息，Recovery 只记录 panic 信息而不输出堆栈。
+	ReleaseMode = "release"
+	// TestMode 关闭 Logger 和 Recovery 的日志输出。
+	TestMode = "test"
+)
+
+const (
+	debugCode = iota
+	releaseCode
+	testCode
+)
+
+var geeMode int32 = debugCode
+
+func init() {
+	SetMode(os.Getenv(EnvGeeMode))
+}
+
+// SetMode 设置 gee 的运行模式，空字符串表示 DebugMode。
+// 参数:
+//   - value: DebugMode、ReleaseMode 或 TestMode，其他值会引发 panic。
+func SetMode(value string) {
+	switch value {
+	case DebugMode, "":
+		atomic.StoreInt32(&geeMode, debugCode)
+	case ReleaseMode:
+		atomic.StoreInt32(&geeMode, releaseCode)
+	case TestMode:
+		atomic.StoreInt32(&geeMode, testCode)
+	default:
+		panic("gee: unknown mode " + value + " (available: debug, release, test)")
+	}
+}
+
+// Mode 返回当前的运行模式。
+func Mode() string {
+	switch atomic.LoadInt32(&geeMode) {
+	case releaseCode:
+		return ReleaseMode
+	case testCode:
+		return TestMode
+	default:
+		return DebugMode
+	}
+}
+
+// IsDebugging 返回当前是否处于 DebugMode。
+func IsDebugging() bool {
+	return atomic.LoadInt32(&geeMode) == debugCode
+}
+
+// debugPrint 仅在 DebugMode 下输出日志。
+func debugPrint(format string, values ...interface{}) {
+	if IsDebugging() {
+		log.Printf("[GEE-debug] "+format, values...)
+	}
+}
+
+// debugPrintWarning 在 DebugMode 下输出配置警告。
+func debugPrintWarning(format string, values ...interface{}) {
+	debugPrint("[WARNING] "+format, values...)
+}
+
+// debugPrintRoute 在 DebugMode 下输出一条路由及其处理函数名称。
+func debugPrintRoute(method, pattern string, handler HandlerFunc) {
+	if IsDebugging() {
+		debugPrint("%-7s %-25s --> %s", method, pattern, nameOfFunction(handler))
+	}
+}
+
+// nameOfFunction 返回函数的完整名称。
+func nameOfFunction(f interface{}) string {
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return fmt.Sprintf("%T", f)
+	}
+	return runtime.FuncForPC(v.Pointer()).Name()
+}
@@ -0,0 +1,79 @@
+package gee
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetMode(t *testing.T) {
+	defer SetMode(DebugMode)
+
+	for _, mode := range []string{ReleaseMode, TestMode, DebugMode} {
+		SetMode(mode)
+		if Mode() != mode {
+			t.Errorf("Mode() = %q, want %q", Mode(), mode)
+		}
+	}
+	SetMode("")
+	if !IsDebugging() {
+		t.Error("empty mode should default to debug")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("SetMode with unknown mode should panic")
+		}
+	}()
+	SetMode("production")
+}
+
+func TestDebugModeWarningOnce(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	debugModeWarned.Store(false)
+
+	New()
+	New()
+	WrapMiddleware(Recovery())
+	CreateTestContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if n := strings.Count(buf.String(), `Running in "debug" mode`); n != 1 {
+		t.Errorf("debug mode warning printed %d times, want 1", n)
+	}
+}
+
+func TestNameOfFunction(t *testing.T) {
+	if name := nameOfFunction(HandlerFunc(Logger())); name != "gee-web/gee-web/07-panic-recover/gee.Logger.func1" {
+		t.Errorf("nameOfFunction = %q", name)
+	}
+}
+
+func TestTemplateHotReload(t *testing.T) {
+	defer SetMode(DebugMode)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "index.tmpl")
+	if err := os.WriteFile(file, []byte(`{{define "index"}}v1{{end}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := New()
+	r.LoadHTMLGlob(filepath.Join(dir, "*.tmpl"))
+	r.GET("/", func(c *Context) { c.HTMLTemplate(http.StatusOK, "index", nil) })
+	if err := os.WriteFile(file, []byte(`{{define "index"}}v2{{end}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for mode, want := range map[string]string{DebugMode: "v2", ReleaseMode: "v1"} {
+		SetMode(mode)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Body.String() != want {
+			t.Errorf("%s mode: body = %q, want %q", mode, w.Body.String(), want)
+		}
+	}
+}
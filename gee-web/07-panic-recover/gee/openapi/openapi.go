@@ -0,0 +1,173 @@
+package openapi
+
+import (
+	_ "embed"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"html/template"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Config 是生成文档的配置。
+type Config struct {
+	Title       string   // 文档标题，默认为 "API"
+	Description string   // 文档说明
+	Version     string   // 接口版本，默认为 "1.0.0"
+	Servers     []string // 服务地址，例如 "https://api.example.com"
+	Host        string   // 只包含该主机模式下的路由，默认主机为空
+}
+
+// Generate 根据 Engine 的路由表生成 OpenAPI 3 文档，跳过 Hide 的路由。
+// 路由模式中的 ":id" 和 "*filepath" 转换为 "{id}" 和 "{filepath}"。
+func Generate(engine *gee.Engine, config Config) *Document {
+	if config.Title == "" {
+		config.Title = "API"
+	}
+	if config.Version == "" {
+		config.Version = "1.0.0"
+	}
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: config.Title, Description: config.Description, Version: config.Version},
+		Paths:   make(map[string]map[string]*Operation),
+	}
+	for _, url := range config.Servers {
+		doc.Servers = append(doc.Servers, Server{URL: url})
+	}
+
+	s := newSchemas()
+	for _, route := range engine.Routes() {
+		if route.Meta.Hidden || route.Host != config.Host {
+			continue
+		}
+		p, pathParams := convertPath(route.Path)
+		if doc.Paths[p] == nil {
+			doc.Paths[p] = make(map[string]*Operation)
+		}
+		doc.Paths[p][strings.ToLower(route.Method)] = operation(s, route, pathParams)
+	}
+	doc.Components.Schemas = s.components
+	return doc
+}
+
+// operation 生成一条路由的 Operation。
+func operation(s *schemas, route *gee.Route, pathParams []string) *Operation {
+	meta := route.Meta
+	op := &Operation{
+		Summary:     meta.Summary,
+		Description: meta.Description,
+		OperationID: meta.OperationID,
+		Tags:        meta.Tags,
+		Deprecated:  meta.Deprecated,
+		Responses:   make(map[string]*Response),
+	}
+
+	declared := make(map[string]bool)
+	if t := structType(meta.Request); t != nil {
+		params, hasBody := s.parameters(t)
+		for _, param := range params {
+			if param.In == "path" {
+				declared[param.Name] = true
+			}
+		}
+		op.Parameters = params
+		if hasBody && route.Method != http.MethodGet && route.Method != http.MethodHead {
+			body := s.of(t)
+			if len(params) > 0 {
+				body = s.object(t, true)
+			}
+			op.RequestBody = &RequestBody{Required: true, Content: jsonContent(body)}
+		}
+	} else if meta.Request != nil {
+		op.RequestBody = &RequestBody{Required: true, Content: jsonContent(s.of(reflect.TypeOf(meta.Request)))}
+	}
+	// 请求结构体中没有声明的路径参数按字符串处理
+	for _, name := range pathParams {
+		if !declared[name] {
+			op.Parameters = append(op.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+
+	if len(meta.Responses) == 0 {
+		op.Responses["200"] = &Response{Description: http.StatusText(http.StatusOK)}
+	}
+	for code, body := range meta.Responses {
+		resp := &Response{Description: http.StatusText(code)}
+		if body != nil {
+			resp.Content = jsonContent(s.of(reflect.TypeOf(body)))
+		}
+		op.Responses[strconv.Itoa(code)] = resp
+	}
+	return op
+}
+
+// structType 返回 v 的结构体类型，v 不是结构体时返回 nil。
+func structType(v interface{}) reflect.Type {
+	if v == nil {
+		return nil
+	}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return nil
+	}
+	return t
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+// convertPath 将 gee 的路由模式转换为 OpenAPI 的路径模板，并返回其中的参数名。
+func convertPath(pattern string) (string, []string) {
+	parts := strings.Split(pattern, "/")
+	var params []string
+	for i, part := range parts {
+		if len(part) > 1 && (part[0] == ':' || part[0] == '*') {
+			params = append(params, part[1:])
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/"), params
+}
+
+//go:embed docs.html
+var docsHTML string
+
+var docsTemplate = template.Must(template.New("docs").Parse(docsHTML))
+
+// Register 在 group 下注册返回 OpenAPI 文档的路由 specPath，docsPath 不为空时同时注册文档页面。
+// 文档在第一次请求时生成，因此之后注册的路由也会包含在内。这两个路由本身不出现在文档中。
+// 参数:
+//   - group: 注册路由的分组，通常是 Engine 本身。
+//   - engine: 生成文档所用的 Engine。
+//   - specPath: 文档 JSON 的路径，例如 "/openapi.json"。
+//   - docsPath: 文档页面的路径，例如 "/docs"，为空时不注册。
+//   - config: 生成文档的配置。
+func Register(group *gee.RouterGroup, engine *gee.Engine, specPath, docsPath string, config Config) {
+	var (
+		once sync.Once
+		doc  *Document
+	)
+	spec := group.GET(specPath, func(c *gee.Context) {
+		once.Do(func() { doc = Generate(engine, config) })
+		c.JSON(http.StatusOK, doc)
+	}).Hide()
+	if docsPath == "" {
+		return
+	}
+	title := config.Title
+	if title == "" {
+		title = "API"
+	}
+	group.GET(docsPath, func(c *gee.Context) {
+		c.SetHeader("Content-Type", "text/html; charset=utf-8")
+		c.Status(http.StatusOK)
+		docsTemplate.Execute(c.Writer, map[string]string{"Title": title, "SpecURL": spec.Path})
+	}).Hide()
+}
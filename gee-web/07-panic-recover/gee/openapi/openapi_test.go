@@ -0,0 +1,115 @@
+package openapi
+
+import (
+	"encoding/json"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"gee-web/gee-web/07-panic-recover/gee/geetest"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type User struct {
+	ID      int64     `json:"id"`
+	Name    string    `json:"name" doc:"display name"`
+	Email   *string   `json:"email,omitempty"`
+	Created time.Time `json:"created"`
+	Friends []User    `json:"friends,omitempty"`
+}
+
+type UpdateUserRequest struct {
+	ID      int64  `path:"id"`
+	TraceID string `header:"X-Trace-ID"`
+	Notify  bool   `query:"notify,required"`
+	Name    string `json:"name"`
+}
+
+func newEngine() *gee.Engine {
+	r := gee.New()
+	api := r.Group("/api")
+	api.GET("/users/:id", func(c *gee.Context) {}).
+		Doc("Get user", "").
+		Tag("users").
+		Returns(http.StatusOK, User{}).
+		Returns(http.StatusNotFound, nil)
+	api.PUT("/users/:id", func(c *gee.Context) {}).
+		Tag("users").
+		Accepts(UpdateUserRequest{}).
+		Returns(http.StatusOK, &User{}).
+		Deprecate()
+	api.GET("/files/*filepath", func(c *gee.Context) {})
+	r.Static("/assets", "./assets")
+	return r
+}
+
+func TestGenerate(t *testing.T) {
+	doc := Generate(newEngine(), Config{Title: "Users"})
+
+	get := doc.Paths["/api/users/{id}"]["get"]
+	if get == nil || get.Summary != "Get user" || get.Tags[0] != "users" {
+		t.Fatalf("get operation = %+v", get)
+	}
+	if get.Responses["200"].Content["application/json"].Schema.Ref != "#/components/schemas/User" {
+		t.Errorf("200 response = %+v", get.Responses["200"])
+	}
+	if get.Responses["404"].Content != nil {
+		t.Error("404 response should have no content")
+	}
+	if len(get.Parameters) != 1 || get.Parameters[0].In != "path" || !get.Parameters[0].Required {
+		t.Errorf("undeclared path parameter = %+v", get.Parameters)
+	}
+
+	put := doc.Paths["/api/users/{id}"]["put"]
+	var ins []string
+	for _, p := range put.Parameters {
+		ins = append(ins, p.Name+":"+p.In+":"+map[bool]string{true: "required"}[p.Required])
+	}
+	if want := []string{"id:path:required", "X-Trace-ID:header:", "notify:query:required"}; !reflect.DeepEqual(ins, want) {
+		t.Errorf("parameters = %v, want %v", ins, want)
+	}
+	body := put.RequestBody.Content["application/json"].Schema
+	if len(body.Properties) != 1 || body.Properties["name"] == nil || !put.Deprecated {
+		t.Errorf("request body = %+v", body)
+	}
+
+	if _, ok := doc.Paths["/api/files/{filepath}"]; !ok {
+		t.Error("wildcard route missing")
+	}
+	for p := range doc.Paths {
+		if strings.HasPrefix(p, "/assets") {
+			t.Errorf("static route %s should be hidden", p)
+		}
+	}
+
+	user := doc.Components.Schemas["User"]
+	if user.Properties["created"].Format != "date-time" || user.Properties["friends"].Items.Ref != "#/components/schemas/User" {
+		t.Errorf("User schema = %+v", user.Properties)
+	}
+	if !reflect.DeepEqual(user.Required, []string{"id", "name", "created"}) || user.Properties["name"].Description != "display name" {
+		t.Errorf("User required = %v", user.Required)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	r := newEngine()
+	Register(r.RouterGroup, r, "/openapi.json", "/docs", Config{Title: "Users"})
+	// 在 Register 之后注册的路由也应出现在文档中
+	r.DELETE("/api/users/:id", func(c *gee.Context) {})
+
+	client := geetest.New(r)
+	w := client.GET("/openapi.json").Do()
+	var doc Document
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.0.3" || doc.Paths["/api/users/{id}"]["delete"] == nil {
+		t.Errorf("spec = %s", w.Body.String())
+	}
+	if _, ok := doc.Paths["/docs"]; ok {
+		t.Error("docs route should be hidden")
+	}
+
+	client.GET("/docs").Expect(t).Status(http.StatusOK).BodyContains(`"/openapi.json"`)
+}
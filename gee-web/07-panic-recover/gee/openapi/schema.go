@@ -0,0 +1,183 @@
+package openapi
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// paramLocations 是字段标签到参数位置的映射，与 gee.Handle 的绑定规则一致。
+var paramLocations = []string{"path", "query", "header"}
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	unsafeRef = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// schemas 通过反射生成 Schema，命名的结构体放入 components 并通过 $ref 引用。
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: make(map[string]*Schema), names: make(map[reflect.Type]string)}
+}
+
+// of 返回类型 t 的 Schema。
+func (s *schemas) of(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return &Schema{Type: "string", Format: "byte"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t, false)
+		}
+		return &Schema{Ref: "#/components/schemas/" + s.register(t)}
+	}
+	// interface{} 等无法描述的类型
+	return &Schema{}
+}
+
+// register 将命名结构体放入 components，返回它的名称；同名的不同类型使用包路径区分。
+func (s *schemas) register(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	name := unsafeRef.ReplaceAllString(t.Name(), "_")
+	if _, taken := s.components[name]; taken {
+		name = unsafeRef.ReplaceAllString(t.PkgPath()+"."+t.Name(), "_")
+	}
+	s.names[t] = name
+	// 先占位，支持递归引用
+	s.components[name] = &Schema{}
+	*s.components[name] = *s.object(t, false)
+	return name
+}
+
+// object 返回结构体的对象 Schema。bodyOnly 为 true 时跳过映射到参数的字段。
+// 字段名取自 json 标签，带 omitempty 或为指针的字段不是必需的，doc 标签作为字段说明。
+func (s *schemas) object(t reflect.Type, bodyOnly bool) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	s.fields(schema, t, bodyOnly)
+	return schema
+}
+
+func (s *schemas) fields(schema *Schema, t reflect.Type, bodyOnly bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if bodyOnly && paramLocation(f) != "" {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.fields(schema, ft, bodyOnly)
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		prop := s.of(ft)
+		// OpenAPI 3.0 会忽略 $ref 旁边的字段，引用类型不附加说明
+		if doc := f.Tag.Get("doc"); doc != "" && prop.Ref == "" {
+			prop.Description = doc
+		}
+		if ft.Kind() == reflect.Ptr {
+			prop.Nullable = prop.Ref == ""
+		}
+		schema.Properties[name] = prop
+		if !strings.Contains(opts, "omitempty") && ft.Kind() != reflect.Ptr {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// paramLocation 返回字段映射到的参数位置，不是参数时返回空字符串。
+func paramLocation(f reflect.StructField) string {
+	for _, in := range paramLocations {
+		if _, ok := f.Tag.Lookup(in); ok {
+			return in
+		}
+	}
+	return ""
+}
+
+// parameters 返回结构体中映射到参数的字段，以及是否存在请求体字段。
+// 标签形如 `query:"page"`，加上 ",required" 表示必需，路径参数总是必需的。
+func (s *schemas) parameters(t reflect.Type) (params []*Parameter, hasBody bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		in := paramLocation(f)
+		if in == "" {
+			if f.Anonymous && f.Tag.Get("json") == "" {
+				ft := f.Type
+				for ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					embedded, body := s.parameters(ft)
+					params = append(params, embedded...)
+					hasBody = hasBody || body
+					continue
+				}
+			}
+			if f.Tag.Get("json") != "-" {
+				hasBody = true
+			}
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get(in), ",")
+		if name == "" {
+			name = f.Name
+		}
+		params = append(params, &Parameter{
+			Name:        name,
+			In:          in,
+			Description: f.Tag.Get("doc"),
+			Required:    in == "path" || opts == "required",
+			Schema:      s.of(f.Type),
+		})
+	}
+	return params, hasBody
+}
@@ -0,0 +1,133 @@
+package proxy
+
+import (
+	"gee-web/gee-web/07-panic-recover/gee"
+	"hash/crc32"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// Balancer 从可用的上游中选出一个，upstreams 不为空且只包含健康、本次请求尚未尝试过的上游。
+type Balancer interface {
+	Pick(c *gee.Context, upstreams []*Upstream) *Upstream
+}
+
+// RoundRobin 返回轮询的负载均衡器。
+func RoundRobin() Balancer {
+	return &roundRobin{}
+}
+
+type roundRobin struct {
+	next uint64
+}
+
+func (b *roundRobin) Pick(c *gee.Context, upstreams []*Upstream) *Upstream {
+	n := atomic.AddUint64(&b.next, 1) - 1
+	return upstreams[n%uint64(len(upstreams))]
+}
+
+// LeastConn 返回选择当前活跃请求数最少的上游的负载均衡器，数量相同时选择靠前的上游。
+func LeastConn() Balancer {
+	return leastConn{}
+}
+
+type leastConn struct{}
+
+func (leastConn) Pick(c *gee.Context, upstreams []*Upstream) *Upstream {
+	best := upstreams[0]
+	for _, u := range upstreams[1:] {
+		if u.Active() < best.Active() {
+			best = u
+		}
+	}
+	return best
+}
+
+// ConsistentHash 返回一致性哈希负载均衡器，相同键的请求总是转发到同一个上游。
+// 哈希环包含所有出现过的上游，不会因为健康状态变化或重试而重建；选中的上游不可用时沿哈希环顺时针选择下一个可用的上游，
+// 因此只有原本映射到不可用上游的键会迁移，上游恢复后这些键会回到原来的上游。
+// 虚拟节点的哈希方式与 gee-cache 的 consistenthash.Map 相同。
+// 参数:
+//   - replicas: 每个上游的虚拟节点数，小于等于 0 时使用 50。
+//   - key: 从请求中提取哈希键，为 nil 时使用客户端 IP。
+func ConsistentHash(replicas int, key func(c *gee.Context) string) Balancer {
+	if replicas <= 0 {
+		replicas = 50
+	}
+	if key == nil {
+		key = clientIP
+	}
+	return &consistentHash{replicas: replicas, key: key, nodes: make(map[uint32]*Upstream), added: make(map[*Upstream]bool)}
+}
+
+type consistentHash struct {
+	replicas int
+	key      func(c *gee.Context) string
+
+	mu    sync.RWMutex
+	ring  []uint32             // 排序后的虚拟节点哈希值
+	nodes map[uint32]*Upstream // 虚拟节点到上游的映射
+	added map[*Upstream]bool   // 已经加入哈希环的上游
+}
+
+func (b *consistentHash) Pick(c *gee.Context, upstreams []*Upstream) *Upstream {
+	b.add(upstreams)
+	available := make(map[*Upstream]bool, len(upstreams))
+	for _, u := range upstreams {
+		available[u] = true
+	}
+	hash := crc32.ChecksumIEEE([]byte(b.key(c)))
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	start := sort.Search(len(b.ring), func(i int) bool { return b.ring[i] >= hash })
+	for i := 0; i < len(b.ring); i++ {
+		if u := b.nodes[b.ring[(start+i)%len(b.ring)]]; available[u] {
+			return u
+		}
+	}
+	return upstreams[0]
+}
+
+// add 将尚未加入哈希环的上游加入哈希环，上游只会在第一次出现时加入一次。
+func (b *consistentHash) add(upstreams []*Upstream) {
+	b.mu.RLock()
+	missing := false
+	for _, u := range upstreams {
+		if !b.added[u] {
+			missing = true
+			break
+		}
+	}
+	b.mu.RUnlock()
+	if !missing {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, u := range upstreams {
+		if b.added[u] {
+			continue
+		}
+		for i := 0; i < b.replicas; i++ {
+			hash := crc32.ChecksumIEEE([]byte(strconv.Itoa(i) + u.URL.String()))
+			b.ring = append(b.ring, hash)
+			b.nodes[hash] = u
+		}
+		b.added[u] = true
+	}
+	sort.Slice(b.ring, func(i, j int) bool { return b.ring[i] < b.ring[j] })
+}
+
+// clientIP 返回连接的远端 IP。
+func clientIP(c *gee.Context) string {
+	host, _, err := net.SplitHostPort(c.Req.RemoteAddr)
+	if err != nil {
+		return c.Req.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,73 @@
+package proxy
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+)
+
+// HealthCheck 是主动健康检查的配置。
+type HealthCheck struct {
+	Path     string        // 检查的路径，例如 "/healthz"
+	Interval time.Duration // 检查间隔，为 0 时不进行主动检查
+	Timeout  time.Duration // 单次检查的超时时间，默认为 Interval
+	// Healthy 根据响应状态码判断上游是否健康，默认为 2xx 和 3xx。
+	Healthy func(status int) bool
+}
+
+// healthLoop 定期检查所有上游，直到 ctx 被取消。
+func (p *Proxy) healthLoop(ctx context.Context) {
+	ticker := time.NewTicker(p.config.HealthCheck.Interval)
+	defer ticker.Stop()
+	p.checkAll(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.checkAll(ctx)
+		}
+	}
+}
+
+// Ping 立即检查一次所有上游的健康状态。
+func (p *Proxy) Ping(ctx context.Context) {
+	p.checkAll(ctx)
+}
+
+// checkAll 并发检查所有上游并更新健康状态。
+func (p *Proxy) checkAll(ctx context.Context) {
+	done := make(chan struct{}, len(p.upstreams))
+	for _, u := range p.upstreams {
+		go func(u *Upstream) {
+			defer func() { done <- struct{}{} }()
+			healthy := p.check(ctx, u)
+			if u.healthy.Swap(healthy) != healthy {
+				log.Printf("[Proxy] upstream %s healthy=%v", u.URL, healthy)
+			}
+		}(u)
+	}
+	for range p.upstreams {
+		<-done
+	}
+}
+
+// check 对单个上游发起一次健康检查。
+func (p *Proxy) check(ctx context.Context, u *Upstream) bool {
+	hc := p.config.HealthCheck
+	ctx, cancel := context.WithTimeout(ctx, hc.Timeout)
+	defer cancel()
+	target := *u.URL
+	target.Path = singleJoiningSlash(target.Path, hc.Path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return false
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return hc.Healthy(resp.StatusCode)
+}
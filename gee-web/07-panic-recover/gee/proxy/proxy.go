@@ -0,0 +1,292 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Upstream 是一个上游服务。
+type Upstream struct {
+	URL     *url.URL
+	healthy atomic.Bool
+	active  atomic.Int64
+}
+
+// Healthy 返回最近一次健康检查的结果，没有开启健康检查时总是 true。
+func (u *Upstream) Healthy() bool {
+	return u.healthy.Load()
+}
+
+// Active 返回正在转发到该上游的请求数。
+func (u *Upstream) Active() int64 {
+	return u.active.Load()
+}
+
+// Config 是反向代理的配置。
+type Config struct {
+	Upstreams []string // 上游地址，例如 "http://10.0.0.1:8080"
+	Balancer  Balancer // 负载均衡器，默认为 RoundRobin()
+	// Retries 是幂等请求（GET、HEAD、OPTIONS、PUT、DELETE、TRACE）在连接上游失败时换一个上游重试的次数。
+	// 带有请求体的请求不会重试，因为请求体已经被读取。
+	Retries     int
+	HealthCheck HealthCheck
+
+	// StripPrefix 从请求路径中去掉的前缀，例如网关上的 "/api"。
+	StripPrefix string
+	// Rewrite 在 StripPrefix 之后改写请求路径，结果再拼接到上游 URL 的路径之后。
+	Rewrite func(path string) string
+	// PreserveHost 为 true 时保留客户端请求的 Host，否则使用上游的 Host。
+	PreserveHost bool
+	// RequestHeaders 设置转发请求的请求头，值为空字符串时删除该请求头。
+	RequestHeaders map[string]string
+	// ResponseHeaders 设置返回给客户端的响应头，值为空字符串时删除该响应头。
+	ResponseHeaders map[string]string
+
+	// Transport 用于转发请求和健康检查，默认为 http.DefaultTransport。
+	Transport http.RoundTripper
+}
+
+// Proxy 是转发到一组上游的反向代理，支持负载均衡、主动健康检查、重试和 WebSocket。
+type Proxy struct {
+	config    Config
+	upstreams []*Upstream
+	rp        *httputil.ReverseProxy
+	client    *http.Client
+	cancel    context.CancelFunc
+}
+
+// attempt 是一次转发尝试，通过请求的 context 传给 ReverseProxy 的回调。
+type attempt struct {
+	upstream *Upstream
+	err      error
+}
+
+type attemptKey struct{}
+
+// ErrNoUpstream 表示没有可用的上游。
+var ErrNoUpstream = errors.New("proxy: no healthy upstream")
+
+// New 创建反向代理，配置了 HealthCheck.Interval 时在后台开始健康检查，需要调用 Close 停止。
+func New(config Config) (*Proxy, error) {
+	if len(config.Upstreams) == 0 {
+		return nil, errors.New("proxy: no upstreams")
+	}
+	if config.Balancer == nil {
+		config.Balancer = RoundRobin()
+	}
+	if config.Transport == nil {
+		config.Transport = http.DefaultTransport
+	}
+	hc := &config.HealthCheck
+	if hc.Timeout <= 0 {
+		hc.Timeout = hc.Interval
+	}
+	if hc.Timeout <= 0 {
+		hc.Timeout = 5 * time.Second
+	}
+	if hc.Healthy == nil {
+		hc.Healthy = func(status int) bool { return status >= 200 && status < 400 }
+	}
+
+	p := &Proxy{config: config, client: &http.Client{Transport: config.Transport}}
+	for _, raw := range config.Upstreams {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("proxy: invalid upstream %q: %w", raw, err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("proxy: upstream %q must be an absolute URL", raw)
+		}
+		upstream := &Upstream{URL: u}
+		upstream.healthy.Store(true)
+		p.upstreams = append(p.upstreams, upstream)
+	}
+	p.rp = &httputil.ReverseProxy{
+		Rewrite:        p.rewrite,
+		ModifyResponse: p.modifyResponse,
+		Transport:      config.Transport,
+		// 不直接写错误响应，由 Handler 决定重试或返回 502
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			r.Context().Value(attemptKey{}).(*attempt).err = err
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	if hc.Interval > 0 {
+		go p.healthLoop(ctx)
+	}
+	return p, nil
+}
+
+// Close 停止健康检查。
+func (p *Proxy) Close() {
+	p.cancel()
+}
+
+// Upstreams 返回所有上游。
+func (p *Proxy) Upstreams() []*Upstream {
+	return p.upstreams
+}
+
+// Handler 返回转发请求的处理函数。它是普通的路由处理函数，所在分组的中间件（认证、限流等）会先执行。
+//
+//	api := r.Group("/api")
+//	api.Use(jwt.New(...))
+//	api.Any("/*path", p.Handler())
+//
+// 没有健康的上游时返回 503，转发失败时返回 502。
+func (p *Proxy) Handler() gee.HandlerFunc {
+	return func(c *gee.Context) {
+		retries := 0
+		if isIdempotent(c.Req.Method) && (c.Req.Body == nil || c.Req.Body == http.NoBody || c.Req.ContentLength == 0) {
+			retries = p.config.Retries
+		}
+		tried := make(map[*Upstream]bool)
+		err := ErrNoUpstream
+		for i := 0; i <= retries; i++ {
+			candidates := p.candidates(tried)
+			if len(candidates) == 0 {
+				break
+			}
+			u := p.config.Balancer.Pick(c, candidates)
+			if u == nil {
+				break
+			}
+			tried[u] = true
+			if err = p.forward(c, u); err == nil {
+				return
+			}
+		}
+		if err == ErrNoUpstream {
+			c.Fail(http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		// 上游地址和传输层错误只记录在日志中，不返回给客户端
+		log.Printf("[Proxy] %s %s: %v", c.Method, c.Req.URL.Path, err)
+		c.Fail(http.StatusBadGateway, http.StatusText(http.StatusBadGateway))
+	}
+}
+
+// candidates 返回健康且尚未尝试过的上游。
+func (p *Proxy) candidates(tried map[*Upstream]bool) []*Upstream {
+	var upstreams []*Upstream
+	for _, u := range p.upstreams {
+		if u.Healthy() && !tried[u] {
+			upstreams = append(upstreams, u)
+		}
+	}
+	return upstreams
+}
+
+// forward 将请求转发到 u，连接失败等没有写出响应的错误会被返回，以便重试。
+func (p *Proxy) forward(c *gee.Context, u *Upstream) error {
+	u.active.Add(1)
+	defer u.active.Add(-1)
+
+	a := &attempt{upstream: u}
+	req := c.Req.WithContext(context.WithValue(c.Req.Context(), attemptKey{}, a))
+	w := &responseWriter{ResponseWriter: c.Writer}
+	p.rp.ServeHTTP(w, req)
+	c.StatusCode = w.status
+	if a.err == nil {
+		return nil
+	}
+	if w.status != 0 {
+		// 已经开始写响应（例如 WebSocket 升级之后出错），不能再重试
+		log.Printf("[Proxy] %s: %v", u.URL.Host, a.err)
+		return nil
+	}
+	return fmt.Errorf("proxy: %s: %w", u.URL.Host, a.err)
+}
+
+// rewrite 改写转发请求的地址、路径和请求头，并设置 X-Forwarded-* 请求头。
+func (p *Proxy) rewrite(pr *httputil.ProxyRequest) {
+	a := pr.In.Context().Value(attemptKey{}).(*attempt)
+	out := pr.Out.URL
+	out.Path = strings.TrimPrefix(out.Path, p.config.StripPrefix)
+	if p.config.Rewrite != nil {
+		out.Path = p.config.Rewrite(out.Path)
+	}
+	if !strings.HasPrefix(out.Path, "/") {
+		out.Path = "/" + out.Path
+	}
+	out.RawPath = ""
+	pr.SetURL(a.upstream.URL)
+	pr.SetXForwarded()
+	if p.config.PreserveHost {
+		pr.Out.Host = pr.In.Host
+	}
+	setHeaders(pr.Out.Header, p.config.RequestHeaders)
+}
+
+// modifyResponse 改写上游返回的响应头。
+func (p *Proxy) modifyResponse(resp *http.Response) error {
+	setHeaders(resp.Header, p.config.ResponseHeaders)
+	return nil
+}
+
+// setHeaders 设置请求头或响应头，值为空字符串时删除。
+func setHeaders(header http.Header, values map[string]string) {
+	for k, v := range values {
+		if v == "" {
+			header.Del(k)
+		} else {
+			header.Set(k, v)
+		}
+	}
+}
+
+// isIdempotent 判断请求方法是否幂等。
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// singleJoiningSlash 用一个 "/" 连接两段路径。
+func singleJoiningSlash(a, b string) string {
+	switch aslash, bslash := strings.HasSuffix(a, "/"), strings.HasPrefix(b, "/"); {
+	case aslash && bslash:
+		return a + b[1:]
+	case !aslash && !bslash:
+		return a + "/" + b
+	}
+	return a + b
+}
+
+// responseWriter 记录转发响应的状态码。
+// 通过 Unwrap 暴露原始的 http.ResponseWriter，ReverseProxy 借助 http.ResponseController 完成 Flush 和 WebSocket 的 Hijack。
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,174 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"gee-web/gee-web/07-panic-recover/gee/geetest"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newUpstream(t *testing.T, name string) *httptest.Server {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			return
+		}
+		if r.Header.Get("Upgrade") == "echo" {
+			conn, rw, _ := http.NewResponseController(w).Hijack()
+			defer conn.Close()
+			rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: echo\r\nConnection: Upgrade\r\n\r\n")
+			rw.Flush()
+			line, _ := rw.ReadString('\n')
+			rw.WriteString(name + ":" + line)
+			rw.Flush()
+			return
+		}
+		w.Header().Set("X-Internal", "secret")
+		fmt.Fprintf(w, "%s %s %s %s", name, r.URL.RequestURI(), r.Header.Get("X-Gateway"), r.Header.Get("X-Forwarded-For"))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newGateway(t *testing.T, config Config) (*gee.Engine, *Proxy) {
+	p, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(p.Close)
+	r := gee.New()
+	api := r.Group("/api")
+	api.Use(func(c *gee.Context) {
+		if c.Req.Header.Get("Authorization") == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+		}
+	})
+	api.Any("/*path", p.Handler())
+	return r, p
+}
+
+func TestRoundRobinAndRewrite(t *testing.T) {
+	a, b := newUpstream(t, "a"), newUpstream(t, "b")
+	r, _ := newGateway(t, Config{
+		Upstreams:       []string{a.URL, b.URL + "/v1"},
+		StripPrefix:     "/api",
+		RequestHeaders:  map[string]string{"X-Gateway": "gee"},
+		ResponseHeaders: map[string]string{"X-Internal": ""},
+	})
+	client := geetest.New(r).WithHeader("Authorization", "Bearer x")
+
+	first := client.GET("/api/users?id=1").Do()
+	second := client.GET("/api/users?id=1").Do()
+	if first.Body.String() != "a /users?id=1 gee 192.0.2.1" || second.Body.String() != "b /v1/users?id=1 gee 192.0.2.1" {
+		t.Errorf("bodies = %q, %q", first.Body.String(), second.Body.String())
+	}
+	if first.Header().Get("X-Internal") != "" {
+		t.Error("response header should be removed")
+	}
+
+	// 分组中间件先于代理执行
+	geetest.New(r).GET("/api/users").Expect(t).Status(http.StatusUnauthorized)
+}
+
+func TestRetryAndHealthCheck(t *testing.T) {
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+	a := newUpstream(t, "a")
+	r, p := newGateway(t, Config{
+		Upstreams:   []string{dead.URL, a.URL},
+		Retries:     1,
+		StripPrefix: "/api",
+		HealthCheck: HealthCheck{Path: "/healthz"},
+	})
+	client := geetest.New(r).WithHeader("Authorization", "Bearer x")
+
+	if w := client.GET("/api/x").Do(); w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "a ") {
+		t.Errorf("GET should be retried on the next upstream: %d %q", w.Code, w.Body.String())
+	}
+	if w := client.POST("/api/x").Do(); w.Code != http.StatusBadGateway || strings.Contains(w.Body.String(), dead.Listener.Addr().String()) {
+		t.Errorf("POST should not be retried and upstream details should be hidden: %d %q", w.Code, w.Body.String())
+	}
+
+	p.Ping(t.Context())
+	if p.Upstreams()[0].Healthy() || !p.Upstreams()[1].Healthy() {
+		t.Fatal("dead upstream should be marked unhealthy")
+	}
+	for i := 0; i < 3; i++ {
+		if w := client.POST("/api/x").Do(); w.Code != http.StatusOK {
+			t.Errorf("unhealthy upstream should be skipped, code = %d", w.Code)
+		}
+	}
+}
+
+func TestBalancers(t *testing.T) {
+	p, err := New(Config{Upstreams: []string{"http://a", "http://b", "http://c"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ups := p.Upstreams()
+	ups[0].active.Add(2)
+	ups[1].active.Add(1)
+	ups[2].active.Add(3)
+	if got := LeastConn().Pick(nil, ups); got != ups[1] {
+		t.Errorf("LeastConn picked %s", got.URL)
+	}
+
+	ch := ConsistentHash(10, func(c *gee.Context) string { return c.Req.Header.Get("X-User") })
+	c, _ := geetest.NewContext(httptest.NewRequest("GET", "/", nil))
+	c.Req.Header.Set("X-User", "42")
+	picked := ch.Pick(c, ups)
+	for i := 0; i < 5; i++ {
+		if ch.Pick(c, ups) != picked {
+			t.Fatal("ConsistentHash should be stable")
+		}
+	}
+	var rest []*Upstream
+	for _, u := range ups {
+		if u != picked {
+			rest = append(rest, u)
+		}
+	}
+	if got := ch.Pick(c, rest); got == picked || got == nil {
+		t.Errorf("ConsistentHash should move the key when its upstream is removed, got %v", got)
+	}
+	// 其他上游上的键不受影响，上游恢复后键回到原来的上游
+	for i := 0; i < 100; i++ {
+		c.Req.Header.Set("X-User", fmt.Sprint(i))
+		before := ch.Pick(c, ups)
+		if after := ch.Pick(c, rest); before != picked && after != before {
+			t.Fatalf("key %d moved from %s to %s", i, before.URL, after.URL)
+		}
+		if again := ch.Pick(c, ups); again != before {
+			t.Fatalf("key %d did not return to %s", i, before.URL)
+		}
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	a := newUpstream(t, "a")
+	r, _ := newGateway(t, Config{Upstreams: []string{a.URL}, StripPrefix: "/api"})
+	gw := httptest.NewServer(r)
+	defer gw.Close()
+
+	conn, err := net.Dial("tcp", gw.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, "GET /api/ws HTTP/1.1\r\nHost: gw\r\nAuthorization: x\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("upgrade failed: %v %v", resp, err)
+	}
+	fmt.Fprint(conn, "ping\n")
+	line, err := br.ReadString('\n')
+	if err != nil && err != io.EOF || line != "a:ping\n" {
+		t.Errorf("echo = %q, %v", line, err)
+	}
+}
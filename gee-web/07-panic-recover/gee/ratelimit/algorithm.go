@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Algorithm 是限流算法。
+// Take 接收某个键当前的状态（首次请求时为 nil），返回新的状态和本次的判定结果。
+// Store 会保证同一个键的 Take 调用是串行的。
+type Algorithm interface {
+	Take(state interface{}, now time.Time) (interface{}, Result)
+}
+
+// TokenBucket 是令牌桶算法：桶中最多存放 Burst 个令牌，每秒补充 Rate 个，每个请求消耗一个。
+type TokenBucket struct {
+	Rate  float64 // 每秒补充的令牌数
+	Burst int     // 桶的容量
+}
+
+// tokenBucketState 是令牌桶的状态。
+type tokenBucketState struct {
+	tokens float64
+	last   time.Time
+}
+
+// Take 实现 Algorithm 接口。
+func (tb *TokenBucket) Take(state interface{}, now time.Time) (interface{}, Result) {
+	s, ok := state.(*tokenBucketState)
+	if !ok {
+		s = &tokenBucketState{tokens: float64(tb.Burst), last: now}
+	}
+	if elapsed := now.Sub(s.last).Seconds(); elapsed > 0 {
+		s.tokens = math.Min(float64(tb.Burst), s.tokens+elapsed*tb.Rate)
+		s.last = now
+	}
+
+	r := Result{Limit: tb.Burst}
+	if s.tokens >= 1 {
+		s.tokens--
+		r.Allowed = true
+	} else {
+		r.RetryAfter = tb.duration(1 - s.tokens)
+	}
+	r.Remaining = int(s.tokens)
+	r.Reset = tb.duration(float64(tb.Burst) - s.tokens)
+	return s, r
+}
+
+// duration 返回补充 n 个令牌需要的时间。
+func (tb *TokenBucket) duration(n float64) time.Duration {
+	if tb.Rate <= 0 {
+		return 0
+	}
+	return time.Duration(n / tb.Rate * float64(time.Second))
+}
+
+// SlidingWindow 是滑动窗口计数算法：任意 Window 时长内最多允许 Limit 个请求。
+// 使用上一个窗口的计数按时间加权估算，只需要保存两个计数器。
+type SlidingWindow struct {
+	Limit  int           // 窗口内允许的请求数
+	Window time.Duration // 窗口时长
+}
+
+// slidingWindowState 是滑动窗口的状态。
+type slidingWindowState struct {
+	start time.Time // 当前窗口的开始时间
+	prev  int       // 上一个窗口的请求数
+	curr  int       // 当前窗口的请求数
+}
+
+// Take 实现 Algorithm 接口。
+func (sw *SlidingWindow) Take(state interface{}, now time.Time) (interface{}, Result) {
+	start := now.Truncate(sw.Window)
+	s, ok := state.(*slidingWindowState)
+	if !ok {
+		s = &slidingWindowState{start: start}
+	}
+	if !s.start.Equal(start) {
+		if start.Sub(s.start) == sw.Window {
+			s.prev = s.curr
+		} else {
+			s.prev = 0
+		}
+		s.curr = 0
+		s.start = start
+	}
+
+	elapsed := now.Sub(start)
+	weight := 1 - float64(elapsed)/float64(sw.Window)
+	count := float64(s.prev)*weight + float64(s.curr)
+
+	r := Result{Limit: sw.Limit, Reset: sw.Window - elapsed}
+	if count+1 <= float64(sw.Limit) {
+		s.curr++
+		count++
+		r.Allowed = true
+	} else if s.prev == 0 || float64(s.curr) >= float64(sw.Limit) {
+		// 即使上一个窗口的权重降为 0 也不够，需要等到下一个窗口
+		r.RetryAfter = sw.Window - elapsed
+	} else {
+		// 等待上一个窗口的权重下降到刚好能容纳一个请求
+		need := (count + 1 - float64(sw.Limit)) / float64(s.prev)
+		r.RetryAfter = time.Duration(need * float64(sw.Window))
+	}
+	r.Remaining = int(math.Max(0, float64(sw.Limit)-count))
+	return s, r
+}
@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"gee-web/gee-web/07-panic-recover/gee"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// KeyFunc 从请求中提取限流的键，返回空字符串时该请求不受限流。
+type KeyFunc func(c *gee.Context) string
+
+// KeyByIP 按客户端 IP 限流，使用连接的远端地址，不信任 X-Forwarded-For 等可伪造的请求头。
+func KeyByIP() KeyFunc {
+	return func(c *gee.Context) string {
+		host, _, err := net.SplitHostPort(c.Req.RemoteAddr)
+		if err != nil {
+			return c.Req.RemoteAddr
+		}
+		return host
+	}
+}
+
+// KeyByHeader 按请求头的值限流，例如按 "X-API-Key" 限制每个 API Key 的请求速率。
+func KeyByHeader(name string) KeyFunc {
+	return func(c *gee.Context) string {
+		if v := c.Req.Header.Get(name); v != "" {
+			return name + ":" + v
+		}
+		return ""
+	}
+}
+
+// KeyByUser 按之前的中间件通过 c.Set(key, user) 保存的用户标识限流。
+func KeyByUser(key string) KeyFunc {
+	return func(c *gee.Context) string {
+		if v := c.GetString(key); v != "" {
+			return "user:" + v
+		}
+		return ""
+	}
+}
+
+// Result 是一次限流判定的结果。
+type Result struct {
+	Allowed    bool          // 是否允许本次请求
+	Limit      int           // 限额
+	Remaining  int           // 剩余可用次数
+	Reset      time.Duration // 距离额度完全恢复的时间
+	RetryAfter time.Duration // 被限流时距离下一次可以请求的时间
+}
+
+// Config 是限流中间件的配置。
+type Config struct {
+	Algorithm Algorithm // 限流算法，例如 &TokenBucket{} 或 &SlidingWindow{}
+	Store     Store     // 限流状态的存储，默认为 NewMemoryStore(16, 10*time.Minute)
+	KeyFunc   KeyFunc   // 限流的键，默认为 KeyByIP()
+}
+
+// New 返回限流中间件。
+// 每个响应都会带上 RateLimit-Limit、RateLimit-Remaining、RateLimit-Reset 头；
+// 被限流的请求返回 429 并带上 Retry-After 头。
+func New(config Config) gee.HandlerFunc {
+	if config.Algorithm == nil {
+		panic("ratelimit: nil Algorithm")
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryStore(16, 10*time.Minute)
+	}
+	if config.KeyFunc == nil {
+		config.KeyFunc = KeyByIP()
+	}
+	return func(c *gee.Context) {
+		key := config.KeyFunc(c)
+		if key == "" {
+			return
+		}
+		var r Result
+		config.Store.Do(key, func(state interface{}) interface{} {
+			state, r = config.Algorithm.Take(state, time.Now())
+			return state
+		})
+
+		header := c.Writer.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(r.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(r.Remaining))
+		header.Set("RateLimit-Reset", seconds(r.Reset))
+		if !r.Allowed {
+			header.Set("Retry-After", seconds(r.RetryAfter))
+			c.Fail(http.StatusTooManyRequests, http.StatusText(http.StatusTooManyRequests))
+		}
+	}
+}
+
+// seconds 将时长向上取整为秒。
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64((d+time.Second-1)/time.Second), 10)
+}
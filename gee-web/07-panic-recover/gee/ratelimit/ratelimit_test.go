@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"gee-web/gee-web/07-panic-recover/gee"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	tb := &TokenBucket{Rate: 1, Burst: 2}
+	now := time.Now()
+	var state interface{}
+	var r Result
+	for i := 0; i < 2; i++ {
+		if state, r = tb.Take(state, now); !r.Allowed {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+	if state, r = tb.Take(state, now); r.Allowed || r.RetryAfter != time.Second {
+		t.Fatalf("burst exhausted, want retry after 1s, got %+v", r)
+	}
+	if _, r = tb.Take(state, now.Add(time.Second)); !r.Allowed {
+		t.Fatal("a token should be refilled after 1s")
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	sw := &SlidingWindow{Limit: 4, Window: time.Minute}
+	start := time.Now().Truncate(time.Minute)
+	var state interface{}
+	var r Result
+	for i := 0; i < 4; i++ {
+		if state, r = sw.Take(state, start); !r.Allowed {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+	if state, r = sw.Take(state, start.Add(30*time.Second)); r.Allowed {
+		t.Fatal("limit reached in current window")
+	}
+	// 下一个窗口过去 1/4 时，上一个窗口的权重为 3/4，估算值为 3，还能再放行 1 个
+	next := start.Add(time.Minute + 15*time.Second)
+	if state, r = sw.Take(state, next); !r.Allowed || r.Remaining != 0 {
+		t.Fatalf("one request should be allowed, got %+v", r)
+	}
+	if _, r = sw.Take(state, next); r.Allowed || r.RetryAfter != 15*time.Second {
+		t.Fatalf("want retry after 15s, got %+v", r)
+	}
+}
+
+func TestMemoryStoreEvict(t *testing.T) {
+	m := NewMemoryStore(4, time.Minute)
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	inc := func(state interface{}) interface{} {
+		n, _ := state.(int)
+		return n + 1
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		m.Do(key, inc)
+	}
+	if m.Len() != 3 {
+		t.Fatalf("expect 3 keys, got %d", m.Len())
+	}
+	now = now.Add(2 * time.Minute)
+	for _, key := range []string{"a", "b", "c"} {
+		m.Do(key, func(state interface{}) interface{} {
+			if state != nil {
+				t.Fatalf("idle key %s should be evicted", key)
+			}
+			return inc(state)
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	r := gee.New()
+	r.Use(New(Config{Algorithm: &TokenBucket{Rate: 0.5, Burst: 1}, KeyFunc: KeyByHeader("X-API-Key")}))
+	r.GET("/", func(c *gee.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	do := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do("a"); w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "1" {
+		t.Fatalf("first request should pass, got %d %v", w.Code, w.Header())
+	}
+	w := do("a")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "2" {
+		t.Fatalf("second request should be limited, got %d %v", w.Code, w.Header())
+	}
+	if w := do("b"); w.Code != http.StatusOK {
+		t.Fatalf("another key should not be limited, got %d", w.Code)
+	}
+}
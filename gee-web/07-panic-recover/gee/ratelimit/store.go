@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// Store 保存每个键的限流状态，实现需要保证并发安全。
+type Store interface {
+	// Do 在 key 的互斥保护下调用 fn，fn 接收 key 当前的状态（不存在时为 nil）并返回新的状态。
+	Do(key string, fn func(state interface{}) interface{})
+}
+
+// entry 是 MemoryStore 中的一个键。
+type entry struct {
+	state    interface{}
+	lastSeen time.Time
+}
+
+// shard 是 MemoryStore 的一个分片，拥有独立的锁，减少不同键之间的锁竞争。
+type shard struct {
+	mu        sync.Mutex
+	entries   map[string]*entry
+	lastSweep time.Time
+}
+
+// MemoryStore 是 Store 的内存实现。
+// 键按哈希分布在多个分片中；超过 idleTimeout 未被访问的键会在之后的访问中被清理。
+type MemoryStore struct {
+	shards      []*shard
+	idleTimeout time.Duration
+	now         func() time.Time // 便于测试替换
+}
+
+// NewMemoryStore 创建一个 MemoryStore。
+// 参数:
+// - shards: int，分片数量，小于 1 时使用 1。
+// - idleTimeout: time.Duration，键的空闲超时时间。
+func NewMemoryStore(shards int, idleTimeout time.Duration) *MemoryStore {
+	if shards < 1 {
+		shards = 1
+	}
+	m := &MemoryStore{
+		shards:      make([]*shard, shards),
+		idleTimeout: idleTimeout,
+		now:         time.Now,
+	}
+	for i := range m.shards {
+		m.shards[i] = &shard{entries: make(map[string]*entry), lastSweep: time.Now()}
+	}
+	return m
+}
+
+// Do 实现 Store 接口。
+func (m *MemoryStore) Do(key string, fn func(state interface{}) interface{}) {
+	s := m.shard(key)
+	now := m.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.lastSweep) >= m.idleTimeout {
+		s.sweep(now, m.idleTimeout)
+	}
+	e, ok := s.entries[key]
+	if !ok {
+		e = &entry{}
+		s.entries[key] = e
+	}
+	e.state = fn(e.state)
+	e.lastSeen = now
+}
+
+// Len 返回当前保存的键的数量。
+func (m *MemoryStore) Len() int {
+	n := 0
+	for _, s := range m.shards {
+		s.mu.Lock()
+		n += len(s.entries)
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// shard 返回 key 所在的分片。
+func (m *MemoryStore) shard(key string) *shard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return m.shards[h.Sum32()%uint32(len(m.shards))]
+}
+
+// sweep 删除分片中所有空闲超时的键，调用方需持有锁。
+func (s *shard) sweep(now time.Time, idleTimeout time.Duration) {
+	for key, e := range s.entries {
+		if now.Sub(e.lastSeen) >= idleTimeout {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -27,13 +27,20 @@ func trace(message string) string {
 }
 
 // Recovery 用于捕获程序运行时panic
+// DebugMode 下记录完整堆栈，ReleaseMode 下只记录panic信息，TestMode 下不输出日志。
 func Recovery() HandlerFunc {
 	return func(c *Context) {
 		defer func() {
 			// 捕获panic
 			if err := recover(); err != nil {
 				message := fmt.Sprintf("%s", err)
-				log.Printf("%s\n\n", trace(message))
+				switch Mode() {
+				case DebugMode:
+					log.Printf("%s\n\n", trace(message))
+				case ReleaseMode:
+					// 生产环境不输出堆栈
+					log.Printf("panic recovered: %s", message)
+				}
 				c.Fail(http.StatusInternalServerError, "Internal Server Error")
 			}
 		}()
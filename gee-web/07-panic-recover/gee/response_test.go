@@ -0,0 +1,100 @@
+package gee
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRedirect(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := newContext(w, httptest.NewRequest(http.MethodGet, "/old", nil))
+	c.Redirect(http.StatusMovedPermanently, "/new")
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/new" || c.StatusCode != w.Code {
+		t.Fatalf("code = %d, Location = %q", w.Code, w.Header().Get("Location"))
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Redirect with 200 should panic")
+		}
+	}()
+	c.Redirect(http.StatusOK, "/new")
+}
+
+func TestFileHelpers(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "report.txt")
+	if err := os.WriteFile(file, []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := New()
+	r.GET("/file", func(c *Context) { c.File(file) })
+	r.GET("/missing", func(c *Context) { c.File(file + ".missing") })
+	r.GET("/fs/*name", func(c *Context) {
+		c.FileFromFS(c.Param("name"), fstest.MapFS{"a.txt": {Data: []byte("from fs")}})
+	})
+	r.GET("/download", func(c *Context) { c.FileAttachment(file, "报告 2024.txt") })
+
+	w := performRequest(r, http.MethodGet, "/file", "", "Range", "bytes=2-4")
+	if w.Code != http.StatusPartialContent || w.Body.String() != "234" {
+		t.Errorf("range: code = %d, body = %q", w.Code, w.Body.String())
+	}
+	etag := performRequest(r, http.MethodGet, "/file", "").Header().Get("ETag")
+	if w := performRequest(r, http.MethodGet, "/file", "", "If-None-Match", etag); w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match: code = %d", w.Code)
+	}
+	if w := performRequest(r, http.MethodGet, "/missing", ""); w.Code != http.StatusNotFound {
+		t.Errorf("missing file: code = %d", w.Code)
+	}
+	if w := performRequest(r, http.MethodGet, "/fs/../a.txt", ""); w.Body.String() != "from fs" {
+		t.Errorf("FileFromFS: body = %q", w.Body.String())
+	}
+
+	w = performRequest(r, http.MethodGet, "/download", "")
+	want := `attachment; filename="__ 2024.txt"; filename*=UTF-8''%E6%8A%A5%E5%91%8A%202024.txt`
+	if got := w.Header().Get("Content-Disposition"); got != want {
+		t.Errorf("Content-Disposition = %q, want %q", got, want)
+	}
+	if got := contentDisposition("attachment", `a "b".txt`); got != `attachment; filename="a \"b\".txt"` {
+		t.Errorf("ASCII Content-Disposition = %q", got)
+	}
+}
+
+func TestDataFromReader(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := newContext(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	c.DataFromReader(http.StatusOK, 5, "text/plain", strings.NewReader("hello"), map[string]string{"X-Source": "reader"})
+	if w.Body.String() != "hello" || w.Header().Get("Content-Length") != "5" || w.Header().Get("X-Source") != "reader" {
+		t.Fatalf("unexpected response: %v %q", w.Header(), w.Body.String())
+	}
+}
+
+func TestHTMLTemplateError(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := `{{define "ok"}}<p>{{.name}}</p>{{end}}{{define "bad"}}<p>partial {{call .load}}</p>{{end}}`
+	if err := os.WriteFile(filepath.Join(dir, "page.tmpl"), []byte(tmpl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := New()
+	r.LoadHTMLGlob(filepath.Join(dir, "*.tmpl"))
+	r.GET("/ok", func(c *Context) { c.HTMLTemplate(http.StatusCreated, "ok", H{"name": "gee"}) })
+	r.GET("/bad", func(c *Context) {
+		c.HTMLTemplate(http.StatusOK, "bad", H{"load": func() (string, error) { return "", errors.New("load failed") }})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if w.Code != http.StatusCreated || w.Body.String() != "<p>gee</p>" || w.Header().Get("Content-Type") != "text/html" {
+		t.Errorf("code = %d, body = %q, headers = %v", w.Code, w.Body.String(), w.Header())
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/bad", nil))
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "partial") {
+		t.Errorf("failed render should only write the error: code = %d, body = %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,84 @@
+package gee
+
+// Route 是已注册的一条路由及其文档元数据，由 GET、POST 等方法返回。
+// 元数据只用于生成接口文档（参见 openapi 包），不影响请求处理。
+//
+//	r.GET("/users/:id", getUser).
+//		Doc("获取用户", "").
+//		Tag("users").
+//		Accepts(GetUserRequest{}).
+//		Returns(http.StatusOK, User{})
+type Route struct {
+	Method  string // 请求方法
+	Path    string // 完整的路由模式，例如 "/users/:id"
+	Host    string // 所属主机的模式，默认主机为空
+	Handler string // 处理函数名称
+	Meta    RouteMeta
+}
+
+// RouteMeta 是路由的文档元数据。
+type RouteMeta struct {
+	Summary     string
+	Description string
+	OperationID string
+	Tags        []string
+	// Request 是请求结构体的零值，字段通过 path、query、header 标签映射到参数，其余 JSON 字段组成请求体。
+	Request interface{}
+	// Responses 是状态码到响应体零值的映射，响应体为 nil 表示没有内容。
+	Responses  map[int]interface{}
+	Deprecated bool
+	Hidden     bool // 不出现在生成的文档中
+}
+
+// Doc 设置路由的摘要和详细说明。
+func (r *Route) Doc(summary, description string) *Route {
+	r.Meta.Summary = summary
+	r.Meta.Description = description
+	return r
+}
+
+// ID 设置路由在文档中的 operationId。
+func (r *Route) ID(operationID string) *Route {
+	r.Meta.OperationID = operationID
+	return r
+}
+
+// Tag 为路由添加分类标签。
+func (r *Route) Tag(tags ...string) *Route {
+	r.Meta.Tags = append(r.Meta.Tags, tags...)
+	return r
+}
+
+// Accepts 设置请求结构体，传入零值即可，例如 CreateUserRequest{}。
+func (r *Route) Accepts(request interface{}) *Route {
+	r.Meta.Request = request
+	return r
+}
+
+// Returns 声明一种响应，body 为响应体的零值，没有响应体时传入 nil。
+func (r *Route) Returns(code int, body interface{}) *Route {
+	if r.Meta.Responses == nil {
+		r.Meta.Responses = make(map[int]interface{})
+	}
+	r.Meta.Responses[code] = body
+	return r
+}
+
+// Deprecate 将路由标记为已废弃。
+func (r *Route) Deprecate() *Route {
+	r.Meta.Deprecated = true
+	return r
+}
+
+// Hide 使路由不出现在生成的文档中。
+func (r *Route) Hide() *Route {
+	r.Meta.Hidden = true
+	return r
+}
+
+// Routes 返回按注册顺序排列的所有路由。
+func (engine *Engine) Routes() []*Route {
+	routes := make([]*Route, len(engine.routes))
+	copy(routes, engine.routes)
+	return routes
+}
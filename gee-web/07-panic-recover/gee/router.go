@@ -141,8 +141,18 @@ func (r *router) handle(c *Context) {
 
 	if n != nil {
 		key := c.Method + "-" + n.pattern
-		c.Params = params
+		if c.Params == nil {
+			c.Params = params
+		} else {
+			// 保留主机参数，合并路径参数
+			for k, v := range params {
+				c.Params[k] = v
+			}
+		}
+		c.fullPath = n.pattern
 		c.handlers = append(c.handlers, r.handlers[key])
+	} else if c.engine != nil && c.engine.noRoute != nil {
+		c.handlers = append(c.handlers, c.engine.noRoute)
 	} else {
 		c.handlers = append(c.handlers, func(c *Context) {
 			c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.Path)
@@ -0,0 +1,101 @@
+package rpcgateway
+
+import (
+	"encoding/json"
+	"errors"
+	geerpc "gee-web/gee-rpc/03-service"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Method 是方法列表中的一项。
+type Method struct {
+	Name  string      `json:"name"`  // "Service.Method"
+	Path  string      `json:"path"`  // 调用该方法的路径，例如 "/rpc/Foo/Sum"
+	Args  interface{} `json:"args"`  // 参数的零值，展示请求体的结构
+	Reply interface{} `json:"reply"` // 返回值的零值，展示响应体的结构
+}
+
+// decodeError 表示请求体不是合法的参数 JSON。
+type decodeError struct{ err error }
+
+func (e *decodeError) Error() string { return "invalid JSON body: " + e.err.Error() }
+
+// Register 在 group 下注册访问 geerpc 服务的 JSON 网关，使浏览器等不支持 gob 协议的客户端可以调用已注册的服务。
+//
+//	POST {path}/:service/:method  请求体为参数的 JSON，响应为返回值的 JSON
+//	GET  {path}                   返回所有可调用的方法
+//
+// 方法通过 server.Call 在当前 goroutine 中调用。找不到方法时返回 404，请求体无法解析时返回 400；
+// 方法返回的错误实现了 gee.StatusCoder 时使用对应的状态码，否则返回 500，DebugMode 以外不返回错误详情。
+// 参数:
+//   - group: 注册路由的分组，可以在分组上挂载认证等中间件。
+//   - path: 网关的路径，例如 "/rpc"。
+//   - server: 提供服务的 geerpc.Server，例如 geerpc.DefaultServer。
+func Register(group *gee.RouterGroup, path string, server *geerpc.Server) {
+	path = strings.TrimSuffix(path, "/")
+	call := group.POST(path+"/:service/:method", func(c *gee.Context) {
+		serviceMethod := c.Param("service") + "." + c.Param("method")
+		reply, err := server.Call(serviceMethod, func(argv interface{}) error {
+			if err := json.NewDecoder(c.Req.Body).Decode(argv); err != nil && err != io.EOF {
+				return &decodeError{err}
+			}
+			return nil
+		})
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, reply)
+	}).Doc("调用 geerpc 方法", "请求体为方法参数的 JSON，响应为返回值的 JSON。")
+
+	base := strings.TrimSuffix(call.Path, ":service/:method")
+	group.GET(path, func(c *gee.Context) {
+		infos := server.Methods()
+		methods := make([]Method, len(infos))
+		for i, info := range infos {
+			methods[i] = Method{
+				Name:  info.Name,
+				Path:  base + strings.Replace(info.Name, ".", "/", 1),
+				Args:  zero(info.ArgType),
+				Reply: zero(info.ReplyType),
+			}
+		}
+		c.JSON(http.StatusOK, methods)
+	}).Doc("列出 geerpc 方法", "")
+}
+
+// fail 根据错误类型返回对应的状态码。
+func fail(c *gee.Context, err error) {
+	var de *decodeError
+	var sc gee.StatusCoder
+	switch {
+	case errors.Is(err, geerpc.ErrNotFound):
+		c.Fail(http.StatusNotFound, err.Error())
+	case errors.As(err, &de):
+		c.Fail(http.StatusBadRequest, err.Error())
+	case errors.As(err, &sc) && sc.StatusCode() < http.StatusInternalServerError:
+		c.Fail(sc.StatusCode(), err.Error())
+	default:
+		code := http.StatusInternalServerError
+		if sc != nil {
+			code = sc.StatusCode()
+		}
+		message := err.Error()
+		if !gee.IsDebugging() {
+			message = http.StatusText(code)
+		}
+		c.Fail(code, message)
+	}
+}
+
+// zero 返回类型 t 的零值，指针类型返回指向零值的指针，以便 JSON 中展示字段。
+func zero(t reflect.Type) interface{} {
+	if t.Kind() == reflect.Ptr {
+		return reflect.New(t.Elem()).Interface()
+	}
+	return reflect.New(t).Elem().Interface()
+}
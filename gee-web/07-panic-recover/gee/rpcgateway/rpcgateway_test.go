@@ -0,0 +1,83 @@
+package rpcgateway
+
+import (
+	"encoding/json"
+	"errors"
+	geerpc "gee-web/gee-rpc/03-service"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"gee-web/gee-web/07-panic-recover/gee/geetest"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type Args struct{ Num1, Num2 int }
+
+type Calc int
+
+func (c Calc) Sum(args Args, reply *int) error {
+	*reply = args.Num1 + args.Num2
+	return nil
+}
+
+func (c Calc) Div(args Args, reply *int) error {
+	if args.Num2 == 0 {
+		return gee.NewHTTPError(http.StatusUnprocessableEntity, "divide by zero")
+	}
+	*reply = args.Num1 / args.Num2
+	return nil
+}
+
+func (c Calc) Fail(args Args, reply *int) error {
+	return errors.New("database is down")
+}
+
+func newTestClient(t *testing.T) *geetest.Client {
+	server := geerpc.NewServer()
+	var calc Calc
+	if err := server.Register(&calc); err != nil {
+		t.Fatal(err)
+	}
+	r := gee.New()
+	Register(r.RouterGroup, "/rpc", server)
+	return geetest.New(r)
+}
+
+// call 以 JSON 请求体调用 path 处的方法。
+func call(client *geetest.Client, path, body string) *geetest.Request {
+	return client.POST(path).WithBody("application/json", strings.NewReader(body))
+}
+
+func TestCall(t *testing.T) {
+	defer gee.SetMode(gee.DebugMode)
+	client := newTestClient(t)
+
+	call(client, "/rpc/Calc/Sum", `{"Num1":2,"Num2":3}`).Expect(t).Status(http.StatusOK).Body("5\n")
+	// 错误实现了 gee.StatusCoder
+	call(client, "/rpc/Calc/Div", `{"Num1":1}`).Expect(t).Status(http.StatusUnprocessableEntity).BodyContains("divide by zero")
+	call(client, "/rpc/Calc/Missing", `{}`).Expect(t).Status(http.StatusNotFound)
+	call(client, "/rpc/Calc/Sum", `{"Num1":`).Expect(t).Status(http.StatusBadRequest)
+
+	gee.SetMode(gee.ReleaseMode)
+	if w := call(client, "/rpc/Calc/Fail", `{}`).Do(); w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "database") {
+		t.Errorf("internal error should be hidden in release mode: code = %d, body = %q", w.Code, w.Body.String())
+	}
+}
+
+func TestMethods(t *testing.T) {
+	w := newTestClient(t).GET("/rpc").Do()
+	var methods []Method
+	if err := json.Unmarshal(w.Body.Bytes(), &methods); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+	if len(methods) != 3 {
+		t.Fatalf("methods = %+v", methods)
+	}
+	sum := methods[2]
+	if sum.Name != "Calc.Sum" || sum.Path != "/rpc/Calc/Sum" {
+		t.Errorf("method = %+v", sum)
+	}
+	if args, _ := json.Marshal(sum.Args); string(args) != `{"Num1":0,"Num2":0}` {
+		t.Errorf("args = %s", args)
+	}
+}
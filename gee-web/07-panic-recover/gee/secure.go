@@ -0,0 +1,180 @@
+package gee
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CSPNonceKey 是当前请求的 CSP nonce 在 Context 中保存时使用的键。
+const CSPNonceKey = "gee-web/csp-nonce"
+
+// SecureConfig 是安全响应头中间件的配置。
+// 每个 Secure 中间件完整地决定它管理的响应头：字段为空时会删除对应的响应头，
+// 因此分组上的 Secure 可以覆盖或关闭 Engine 上 Secure 设置的响应头。
+type SecureConfig struct {
+	// AllowedHosts 是允许的 Host，支持形如 "*.example.com" 的通配符，为空时不检查。不匹配的请求返回 400。
+	AllowedHosts []string
+	// SSLRedirect 为 true 时将 HTTP 请求重定向到 HTTPS，GET、HEAD 使用 301，其他方法使用 308。
+	// 开启时必须设置 SSLHost 或 AllowedHosts，避免使用客户端伪造的 Host 构造重定向地址。
+	SSLRedirect bool
+	// SSLHost 是重定向的目标主机，为空时使用通过 AllowedHosts 检查的请求 Host。
+	SSLHost string
+	// SSLProxyHeaders 用于在反向代理之后判断请求是否为 HTTPS，例如 {"X-Forwarded-Proto": "https"}。
+	SSLProxyHeaders map[string]string
+
+	// STSSeconds 是 Strict-Transport-Security 的 max-age，为 0 时不发送。只在 HTTPS 请求中发送。
+	STSSeconds           int64
+	STSIncludeSubdomains bool
+	STSPreload           bool
+
+	// ContentSecurityPolicy 是 CSP 策略，其中的 "{nonce}" 会被替换为每个请求随机生成的 nonce，
+	// 例如 "script-src 'self' 'nonce-{nonce}'"，模板中通过 CSPNonce 获取同一个 nonce。
+	ContentSecurityPolicy string
+	// FrameOptions 是 X-Frame-Options，例如 "DENY" 或 "SAMEORIGIN"。
+	FrameOptions string
+	// ContentTypeNosniff 为 true 时发送 X-Content-Type-Options: nosniff。
+	ContentTypeNosniff bool
+	// ReferrerPolicy 是 Referrer-Policy。
+	ReferrerPolicy string
+	// PermissionsPolicy 是 Permissions-Policy。
+	PermissionsPolicy string
+}
+
+// DefaultSecureConfig 返回推荐的默认配置：一年的 HSTS、只允许同源资源的 CSP、禁止被嵌入、
+// nosniff、strict-origin-when-cross-origin，并禁用摄像头、麦克风和定位。不开启 HTTPS 重定向和 Host 检查。
+func DefaultSecureConfig() SecureConfig {
+	return SecureConfig{
+		STSSeconds:            31536000,
+		STSIncludeSubdomains:  true,
+		ContentSecurityPolicy: "default-src 'self'; script-src 'self' 'nonce-{nonce}'; object-src 'none'; base-uri 'self'; frame-ancestors 'none'",
+		FrameOptions:          "DENY",
+		ContentTypeNosniff:    true,
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+		PermissionsPolicy:     "camera=(), microphone=(), geolocation=()",
+	}
+}
+
+// Secure 返回设置安全响应头的中间件，可以通过 Use 挂载到 Engine 或任意 RouterGroup 上。
+// SSLRedirect 开启而 SSLHost 和 AllowedHosts 都为空时 panic。
+func Secure(config SecureConfig) HandlerFunc {
+	if config.SSLRedirect && config.SSLHost == "" && len(config.AllowedHosts) == 0 {
+		panic("gee: SSLRedirect requires SSLHost or AllowedHosts")
+	}
+	sts := ""
+	if config.STSSeconds > 0 {
+		sts = "max-age=" + strconv.FormatInt(config.STSSeconds, 10)
+		if config.STSIncludeSubdomains {
+			sts += "; includeSubDomains"
+		}
+		if config.STSPreload {
+			sts += "; preload"
+		}
+	}
+	if config.STSPreload && (config.STSSeconds < 31536000 || !config.STSIncludeSubdomains) {
+		debugPrintWarning("HSTS preload requires max-age of at least one year and includeSubDomains.")
+	}
+	nonce := strings.Contains(config.ContentSecurityPolicy, "{nonce}")
+
+	return func(c *Context) {
+		if len(config.AllowedHosts) > 0 && !hostAllowed(config.AllowedHosts, c.Req.Host) {
+			c.Fail(http.StatusBadRequest, "Bad Host")
+			return
+		}
+		https := isHTTPS(c.Req, config.SSLProxyHeaders)
+		if config.SSLRedirect && !https {
+			host := config.SSLHost
+			if host == "" {
+				host = c.Req.Host
+			}
+			code := http.StatusPermanentRedirect
+			if c.Method == http.MethodGet || c.Method == http.MethodHead {
+				code = http.StatusMovedPermanently
+			}
+			c.Redirect(code, "https://"+host+c.Req.URL.RequestURI())
+			c.Abort()
+			return
+		}
+
+		header := c.Writer.Header()
+		if https {
+			setOrDel(header, "Strict-Transport-Security", sts)
+		}
+		csp := config.ContentSecurityPolicy
+		if nonce {
+			n := newNonce()
+			c.Set(CSPNonceKey, n)
+			csp = strings.ReplaceAll(csp, "{nonce}", n)
+		}
+		setOrDel(header, "Content-Security-Policy", csp)
+		setOrDel(header, "X-Frame-Options", config.FrameOptions)
+		if config.ContentTypeNosniff {
+			header.Set("X-Content-Type-Options", "nosniff")
+		} else {
+			header.Del("X-Content-Type-Options")
+		}
+		setOrDel(header, "Referrer-Policy", config.ReferrerPolicy)
+		setOrDel(header, "Permissions-Policy", config.PermissionsPolicy)
+	}
+}
+
+// CSPNonce 返回 Secure 中间件为当前请求生成的 CSP nonce，没有时返回空字符串。
+// 在模板中使用：<script nonce="{{ cspNonce .ctx }}">…</script>
+func CSPNonce(c *Context) string {
+	return c.GetString(CSPNonceKey)
+}
+
+// SecureFuncMap 返回可以通过 Engine.SetFuncMap 注册的模板函数 cspNonce，需要在 LoadHTMLGlob 之前调用。
+// 模板数据中需要传入当前的 *gee.Context，例如 gee.H{"ctx": c}。
+func SecureFuncMap() template.FuncMap {
+	return template.FuncMap{"cspNonce": CSPNonce}
+}
+
+// newNonce 返回 128 位的随机 nonce。
+func newNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// setOrDel 设置响应头，value 为空时删除。
+func setOrDel(header http.Header, key, value string) {
+	if value == "" {
+		header.Del(key)
+		return
+	}
+	header.Set(key, value)
+}
+
+// isHTTPS 判断请求是否通过 HTTPS 到达。
+func isHTTPS(req *http.Request, proxyHeaders map[string]string) bool {
+	if req.TLS != nil || strings.EqualFold(req.URL.Scheme, "https") {
+		return true
+	}
+	for k, v := range proxyHeaders {
+		if strings.EqualFold(req.Header.Get(k), v) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostAllowed 判断请求的 Host（去掉端口）是否在允许列表中。
+func hostAllowed(allowed []string, host string) bool {
+	host = normalizeHost(host)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if pattern == host {
+			return true
+		}
+		if strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:]) && len(host) > len(pattern)-1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package gee
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSecureHeaders(t *testing.T) {
+	r := New()
+	r.Use(Secure(DefaultSecureConfig()))
+	r.GET("/", func(c *Context) { c.String(http.StatusOK, "%s", CSPNonce(c)) })
+	embed := r.Group("/embed")
+	config := DefaultSecureConfig()
+	config.FrameOptions = ""
+	embed.Use(Secure(config))
+	embed.GET("/widget", func(c *Context) { c.String(http.StatusOK, "widget") })
+
+	w := performRequest(r, http.MethodGet, "https://example.com/", "")
+	h := w.Header()
+	if h.Get("Strict-Transport-Security") != "max-age=31536000; includeSubDomains" ||
+		h.Get("X-Frame-Options") != "DENY" || h.Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("unexpected headers: %v", h)
+	}
+	nonce := w.Body.String()
+	if nonce == "" || !strings.Contains(h.Get("Content-Security-Policy"), "'nonce-"+nonce+"'") {
+		t.Errorf("nonce %q not in CSP %q", nonce, h.Get("Content-Security-Policy"))
+	}
+
+	w = performRequest(r, http.MethodGet, "/", "")
+	if w.Header().Get("Strict-Transport-Security") != "" || w.Body.String() == nonce {
+		t.Error("HSTS should only be sent over HTTPS and nonce should change per request")
+	}
+
+	w = performRequest(r, http.MethodGet, "/embed/widget", "")
+	if w.Header().Get("X-Frame-Options") != "" {
+		t.Error("group override should remove X-Frame-Options")
+	}
+}
+
+func TestSecureRedirectAndHosts(t *testing.T) {
+	r := New()
+	r.Use(Secure(SecureConfig{
+		AllowedHosts:    []string{"example.com", "*.example.com"},
+		SSLRedirect:     true,
+		SSLProxyHeaders: map[string]string{"X-Forwarded-Proto": "https"},
+	}))
+	r.GET("/", func(c *Context) { c.String(http.StatusOK, "ok") })
+	r.POST("/", func(c *Context) { c.String(http.StatusOK, "ok") })
+
+	tests := []struct {
+		method, host, proto string
+		code                int
+		location            string
+	}{
+		{http.MethodGet, "example.com", "", http.StatusMovedPermanently, "https://example.com/?q=1"},
+		{http.MethodPost, "api.example.com:8080", "", http.StatusPermanentRedirect, "https://api.example.com:8080/?q=1"},
+		{http.MethodGet, "api.example.com", "https", http.StatusOK, ""},
+		{http.MethodGet, "evil.com", "https", http.StatusBadRequest, ""},
+		{http.MethodGet, "notexample.com", "https", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		w := performRequest(r, tt.method, "http://"+tt.host+"/?q=1", "", "X-Forwarded-Proto", tt.proto)
+		if w.Code != tt.code || w.Header().Get("Location") != tt.location {
+			t.Errorf("%s %s: code = %d, Location = %q", tt.method, tt.host, w.Code, w.Header().Get("Location"))
+		}
+	}
+}
+
+func TestSecureRedirectRequiresHost(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("SSLRedirect without SSLHost or AllowedHosts should panic")
+		}
+	}()
+	Secure(SecureConfig{SSLRedirect: true})
+}
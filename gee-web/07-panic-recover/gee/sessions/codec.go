@@ -0,0 +1,117 @@
+package sessions
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/gob"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidCookie 表示 Cookie 格式错误、签名不匹配或无法解密。
+	ErrInvalidCookie = errors.New("sessions: invalid cookie")
+	// ErrExpiredCookie 表示 Cookie 的时间戳已超过有效期。
+	ErrExpiredCookie = errors.New("sessions: expired cookie")
+)
+
+// codec 负责对 Cookie 值进行签名和加密。
+// 编码格式为 base64(ciphertext|timestamp|mac)，mac 覆盖了 Cookie 名称，防止值在不同 Cookie 之间挪用。
+type codec struct {
+	hashKey []byte      // HMAC-SHA256 签名密钥
+	aead    cipher.AEAD // AES-GCM 加密器，为 nil 时只签名不加密
+}
+
+// newCodec 创建一个 codec。
+// hashKey 不能为空；blockKey 为空时不加密，否则长度必须为 16、24 或 32 字节。
+func newCodec(hashKey, blockKey []byte) *codec {
+	if len(hashKey) == 0 {
+		panic("sessions: hash key is required")
+	}
+	c := &codec{hashKey: hashKey}
+	if len(blockKey) > 0 {
+		block, err := aes.NewCipher(blockKey)
+		if err != nil {
+			panic("sessions: invalid block key: " + err.Error())
+		}
+		if c.aead, err = cipher.NewGCM(block); err != nil {
+			panic("sessions: " + err.Error())
+		}
+	}
+	return c
+}
+
+// encode 将 value 序列化、加密并签名。
+func (c *codec) encode(name string, value interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		return "", err
+	}
+	b := buf.Bytes()
+	if c.aead != nil {
+		nonce := make([]byte, c.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		b = c.aead.Seal(nonce, nonce, b, []byte(name))
+	}
+	payload := base64.RawURLEncoding.EncodeToString(b) + "|" + strconv.FormatInt(time.Now().Unix(), 10)
+	mac := base64.RawURLEncoding.EncodeToString(c.mac(name, payload))
+	return base64.RawURLEncoding.EncodeToString([]byte(payload + "|" + mac)), nil
+}
+
+// decode 校验签名和有效期，解密并反序列化到 dst 中。
+// maxAge 小于等于 0 时不检查时间戳。
+func (c *codec) decode(name, value string, maxAge int, dst interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return ErrInvalidCookie
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 {
+		return ErrInvalidCookie
+	}
+	payload := parts[0] + "|" + parts[1]
+	mac, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(mac, c.mac(name, payload)) {
+		return ErrInvalidCookie
+	}
+	ts, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return ErrInvalidCookie
+	}
+	if maxAge > 0 && time.Now().Unix()-ts > int64(maxAge) {
+		return ErrExpiredCookie
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return ErrInvalidCookie
+	}
+	if c.aead != nil {
+		size := c.aead.NonceSize()
+		if len(b) < size {
+			return ErrInvalidCookie
+		}
+		if b, err = c.aead.Open(nil, b[:size], b[size:], []byte(name)); err != nil {
+			return ErrInvalidCookie
+		}
+	}
+	if err = gob.NewDecoder(bytes.NewReader(b)).Decode(dst); err != nil {
+		return ErrInvalidCookie
+	}
+	return nil
+}
+
+// mac 计算 name|payload 的 HMAC-SHA256。
+func (c *codec) mac(name, payload string) []byte {
+	h := hmac.New(sha256.New, c.hashKey)
+	h.Write([]byte(name + "|" + payload))
+	return h.Sum(nil)
+}
@@ -0,0 +1,93 @@
+package sessions
+
+import (
+	"sync"
+	"time"
+)
+
+// memoryItem 是 MemoryBackend 中保存的一条会话数据。
+type memoryItem struct {
+	values  map[string]interface{}
+	expires time.Time
+}
+
+// MemoryBackend 是 Backend 的内存实现，数据在进程重启后丢失。
+// 过期数据在读取时删除，同时每隔 gcInterval 在写入时清理一次。
+type MemoryBackend struct {
+	mu         sync.Mutex
+	items      map[string]memoryItem
+	gcInterval time.Duration
+	lastGC     time.Time
+	now        func() time.Time // 便于测试替换
+}
+
+// NewMemoryBackend 创建一个 MemoryBackend。
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		items:      make(map[string]memoryItem),
+		gcInterval: time.Minute,
+		lastGC:     time.Now(),
+		now:        time.Now,
+	}
+}
+
+// Load 实现 Backend 接口。
+func (m *MemoryBackend) Load(id string) (map[string]interface{}, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.items[id]
+	if !ok {
+		return nil, false, nil
+	}
+	if !m.now().Before(item.expires) {
+		delete(m.items, id)
+		return nil, false, nil
+	}
+	return copyValues(item.values), true, nil
+}
+
+// Save 实现 Backend 接口。
+func (m *MemoryBackend) Save(id string, values map[string]interface{}, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.items[id] = memoryItem{values: copyValues(values), expires: now.Add(ttl)}
+	if now.Sub(m.lastGC) >= m.gcInterval {
+		m.gc(now)
+	}
+	return nil
+}
+
+// Delete 实现 Backend 接口。
+func (m *MemoryBackend) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, id)
+	return nil
+}
+
+// Len 返回当前保存的会话数量（包括尚未清理的过期会话）。
+func (m *MemoryBackend) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.items)
+}
+
+// gc 删除所有过期的会话，调用方需持有锁。
+func (m *MemoryBackend) gc(now time.Time) {
+	for id, item := range m.items {
+		if !now.Before(item.expires) {
+			delete(m.items, id)
+		}
+	}
+	m.lastGC = now
+}
+
+// copyValues 浅拷贝会话数据，避免存储与请求之间共享同一个 map。
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,170 @@
+package sessions
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"log"
+	"net/http"
+)
+
+// DefaultKey 是会话注册表在 gee.Context 中保存时使用的键。
+const DefaultKey = "gee-web/sessions"
+
+// Options 描述了会话 Cookie 的属性。
+type Options struct {
+	Path     string        // Cookie 路径
+	Domain   string        // Cookie 所属域名
+	MaxAge   int           // 有效期（秒），小于 0 表示删除会话，0 表示会话 Cookie
+	Secure   bool          // 是否仅通过 HTTPS 发送
+	HttpOnly bool          // 是否禁止脚本访问
+	SameSite http.SameSite // SameSite 属性
+}
+
+// DefaultOptions 返回默认的会话 Cookie 属性：7 天有效、HttpOnly、SameSite=Lax。
+func DefaultOptions() Options {
+	return Options{
+		Path:     "/",
+		MaxAge:   86400 * 7,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// cookie 根据 Options 构造一个 http.Cookie。
+func (o Options) cookie(name, value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     o.Path,
+		Domain:   o.Domain,
+		MaxAge:   o.MaxAge,
+		Secure:   o.Secure,
+		HttpOnly: o.HttpOnly,
+		SameSite: o.SameSite,
+	}
+}
+
+// Store 是会话存储的抽象，负责从请求中加载会话以及持久化会话。
+type Store interface {
+	// Load 从请求中加载名为 name 的会话。
+	// 会话不存在或无效时返回一个新会话；无效时同时返回错误。
+	Load(c *gee.Context, name string) (*Session, error)
+	// Save 持久化会话，并向响应写出会话 Cookie。
+	Save(c *gee.Context, s *Session) error
+}
+
+// Session 表示一次请求中加载的会话。
+type Session struct {
+	ID      string                 // 会话 ID
+	Values  map[string]interface{} // 会话数据
+	Options Options                // 会话 Cookie 的属性
+	IsNew   bool                   // 是否为本次请求新建的会话
+
+	name     string
+	store    Store
+	ctx      *gee.Context
+	replaced []string // 被 Regenerate 废弃、待 Save 时清理的会话 ID
+}
+
+// NewSession 创建一个属于 store 的新会话，供 Store 的实现使用。
+// 参数:
+// - store: Store，会话所属的存储。
+// - name: string，会话名称，同时也是 Cookie 名称。
+// - options: Options，会话 Cookie 的属性。
+func NewSession(store Store, name string, options Options) *Session {
+	return &Session{
+		ID:      newID(),
+		Values:  make(map[string]interface{}),
+		Options: options,
+		IsNew:   true,
+		name:    name,
+		store:   store,
+	}
+}
+
+// Name 返回会话名称。
+func (s *Session) Name() string {
+	return s.name
+}
+
+// Get 返回会话中 key 对应的值，不存在时返回 nil。
+func (s *Session) Get(key string) interface{} {
+	return s.Values[key]
+}
+
+// Set 设置会话中 key 对应的值。
+func (s *Session) Set(key string, value interface{}) {
+	s.Values[key] = value
+}
+
+// Delete 删除会话中 key 对应的值。
+func (s *Session) Delete(key string) {
+	delete(s.Values, key)
+}
+
+// Clear 清空会话中的所有数据。
+func (s *Session) Clear() {
+	s.Values = make(map[string]interface{})
+}
+
+// Save 持久化会话，必须在写出响应体之前调用。
+func (s *Session) Save() error {
+	return s.store.Save(s.ctx, s)
+}
+
+// Regenerate 为会话更换新的 ID 并保留数据，用于登录等权限变化后防止会话固定攻击。
+// 旧的 ID 会在下一次 Save 时从存储中删除。
+func (s *Session) Regenerate() {
+	if !s.IsNew {
+		s.replaced = append(s.replaced, s.ID)
+	}
+	s.ID = newID()
+	s.IsNew = true
+}
+
+// newID 生成一个随机的会话 ID。
+func newID() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic("sessions: failed to generate id: " + err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// registry 在一次请求内缓存已经加载的会话。
+type registry struct {
+	name    string
+	store   Store
+	ctx     *gee.Context
+	session *Session
+}
+
+// get 返回当前请求的会话，首次调用时从存储中加载。
+func (r *registry) get() *Session {
+	if r.session == nil {
+		s, err := r.store.Load(r.ctx, r.name)
+		if err != nil {
+			log.Printf("[Sessions] load %s: %v", r.name, err)
+		}
+		s.ctx = r.ctx
+		r.session = s
+	}
+	return r.session
+}
+
+// Sessions 返回会话中间件，会话在第一次调用 Default 时才会加载。
+// 参数:
+// - name: string，会话名称，同时也是 Cookie 名称。
+// - store: Store，会话存储。
+func Sessions(name string, store Store) gee.HandlerFunc {
+	return func(c *gee.Context) {
+		c.Set(DefaultKey, &registry{name: name, store: store, ctx: c})
+		c.Next()
+	}
+}
+
+// Default 返回当前请求的会话，必须在 Sessions 中间件之后使用。
+func Default(c *gee.Context) *Session {
+	return c.MustGet(DefaultKey).(*registry).get()
+}
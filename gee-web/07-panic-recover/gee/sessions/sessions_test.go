@@ -0,0 +1,118 @@
+package sessions
+
+import (
+	"gee-web/gee-web/07-panic-recover/gee"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var (
+	hashKey  = []byte("0123456789abcdef0123456789abcdef")
+	blockKey = []byte("fedcba9876543210")
+)
+
+// newTestEngine 创建一个带会话中间件的 Engine，/set 写入会话，/get 读取会话。
+func newTestEngine(store Store) *gee.Engine {
+	r := gee.New()
+	r.Use(Sessions("geesession", store))
+	r.GET("/set", func(c *gee.Context) {
+		s := Default(c)
+		s.Set("user", c.Query("user"))
+		if err := s.Save(); err != nil {
+			c.Fail(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.String(http.StatusOK, "%s", s.ID)
+	})
+	r.GET("/get", func(c *gee.Context) {
+		user, _ := Default(c).Get("user").(string)
+		c.String(http.StatusOK, "%s", user)
+	})
+	r.GET("/regenerate", func(c *gee.Context) {
+		s := Default(c)
+		s.Regenerate()
+		if err := s.Save(); err != nil {
+			c.Fail(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.String(http.StatusOK, "%s", s.ID)
+	})
+	return r
+}
+
+// do 发送一个携带 cookies 的 GET 请求。
+func do(r http.Handler, path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCookieStore(t *testing.T) {
+	r := newTestEngine(NewCookieStore(hashKey, blockKey))
+	w := do(r, "/set?user=geektutu")
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || !cookies[0].HttpOnly {
+		t.Fatalf("expect one HttpOnly session cookie, got %v", cookies)
+	}
+	if body := do(r, "/get", cookies...).Body.String(); body != "geektutu" {
+		t.Fatalf("expect geektutu, got %q", body)
+	}
+
+	tampered := *cookies[0]
+	if tampered.Value[0] == 'x' {
+		tampered.Value = "y" + tampered.Value[1:]
+	} else {
+		tampered.Value = "x" + tampered.Value[1:]
+	}
+	if body := do(r, "/get", &tampered).Body.String(); body != "" {
+		t.Fatalf("tampered cookie should be rejected, got %q", body)
+	}
+}
+
+func TestBackendStore(t *testing.T) {
+	backend := NewMemoryBackend()
+	r := newTestEngine(NewBackendStore(backend, hashKey, blockKey))
+	w := do(r, "/set?user=geektutu")
+	cookies := w.Result().Cookies()
+	oldID := w.Body.String()
+	if body := do(r, "/get", cookies...).Body.String(); body != "geektutu" {
+		t.Fatalf("expect geektutu, got %q", body)
+	}
+
+	w = do(r, "/regenerate", cookies...)
+	if w.Body.String() == oldID {
+		t.Fatal("Regenerate should change the session id")
+	}
+	if _, ok, _ := backend.Load(oldID); ok {
+		t.Fatal("old session should be deleted after Regenerate")
+	}
+	if body := do(r, "/get", w.Result().Cookies()...).Body.String(); body != "geektutu" {
+		t.Fatalf("values should survive Regenerate, got %q", body)
+	}
+}
+
+func TestMemoryBackendTTL(t *testing.T) {
+	m := NewMemoryBackend()
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	_ = m.Save("a", map[string]interface{}{"k": 1}, time.Second)
+	_ = m.Save("b", map[string]interface{}{"k": 2}, time.Hour)
+	if _, ok, _ := m.Load("a"); !ok {
+		t.Fatal("a should not expire yet")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok, _ := m.Load("a"); ok {
+		t.Fatal("a should be expired")
+	}
+	_ = m.Save("c", nil, time.Hour)
+	if m.Len() != 2 {
+		t.Fatalf("expect 2 sessions after gc, got %d", m.Len())
+	}
+}
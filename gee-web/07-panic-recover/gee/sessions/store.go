@@ -0,0 +1,160 @@
+package sessions
+
+import (
+	"errors"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"net/http"
+	"time"
+)
+
+// maxCookieSize 是浏览器普遍支持的单个 Cookie 的最大长度。
+const maxCookieSize = 4096
+
+// ErrCookieTooLarge 表示编码后的会话数据超出了 Cookie 的长度限制。
+var ErrCookieTooLarge = errors.New("sessions: cookie value too large")
+
+// cookiePayload 是 CookieStore 写入 Cookie 的内容。
+type cookiePayload struct {
+	ID     string
+	Values map[string]interface{}
+}
+
+// CookieStore 将整个会话签名、加密后保存在 Cookie 中，服务端不保存任何状态。
+// 会话中保存自定义类型时需要先调用 gob.Register 注册。
+type CookieStore struct {
+	Options Options // 新会话使用的 Cookie 属性
+	codec   *codec
+}
+
+// NewCookieStore 创建一个 CookieStore。
+// 参数:
+// - hashKey: []byte，签名密钥，建议 32 或 64 字节。
+// - blockKey: []byte，AES 加密密钥，长度为 16、24 或 32 字节；为空时只签名不加密。
+func NewCookieStore(hashKey, blockKey []byte) *CookieStore {
+	return &CookieStore{
+		Options: DefaultOptions(),
+		codec:   newCodec(hashKey, blockKey),
+	}
+}
+
+// Load 实现 Store 接口。
+func (s *CookieStore) Load(c *gee.Context, name string) (*Session, error) {
+	session := NewSession(s, name, s.Options)
+	cookie, err := c.Req.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	var payload cookiePayload
+	if err = s.codec.decode(name, cookie.Value, s.Options.MaxAge, &payload); err != nil {
+		return session, err
+	}
+	session.ID = payload.ID
+	if payload.Values != nil {
+		session.Values = payload.Values
+	}
+	session.IsNew = false
+	return session, nil
+}
+
+// Save 实现 Store 接口。
+func (s *CookieStore) Save(c *gee.Context, session *Session) error {
+	if session.Options.MaxAge < 0 {
+		http.SetCookie(c.Writer, session.Options.cookie(session.name, ""))
+		return nil
+	}
+	value, err := s.codec.encode(session.name, cookiePayload{ID: session.ID, Values: session.Values})
+	if err != nil {
+		return err
+	}
+	if len(value) > maxCookieSize {
+		return ErrCookieTooLarge
+	}
+	http.SetCookie(c.Writer, session.Options.cookie(session.name, value))
+	session.IsNew = false
+	session.replaced = nil
+	return nil
+}
+
+// Backend 是服务端会话数据的存储接口，实现需要保证并发安全。
+type Backend interface {
+	// Load 返回 id 对应的会话数据，ok 为 false 表示不存在或已过期。
+	Load(id string) (values map[string]interface{}, ok bool, err error)
+	// Save 保存 id 对应的会话数据，ttl 为数据的存活时间。
+	Save(id string, values map[string]interface{}, ttl time.Duration) error
+	// Delete 删除 id 对应的会话数据。
+	Delete(id string) error
+}
+
+// BackendStore 将会话数据保存在服务端的 Backend 中，Cookie 中只保存签名、加密后的会话 ID。
+type BackendStore struct {
+	Options Options // 新会话使用的 Cookie 属性
+	backend Backend
+	codec   *codec
+}
+
+// NewBackendStore 创建一个 BackendStore。
+// 参数:
+// - backend: Backend，服务端会话数据的存储。
+// - hashKey: []byte，签名密钥。
+// - blockKey: []byte，AES 加密密钥，为空时只签名不加密。
+func NewBackendStore(backend Backend, hashKey, blockKey []byte) *BackendStore {
+	return &BackendStore{
+		Options: DefaultOptions(),
+		backend: backend,
+		codec:   newCodec(hashKey, blockKey),
+	}
+}
+
+// Load 实现 Store 接口。
+// 存储中找不到对应数据时返回一个使用新 ID 的会话，而不是沿用客户端提供的 ID。
+func (s *BackendStore) Load(c *gee.Context, name string) (*Session, error) {
+	session := NewSession(s, name, s.Options)
+	cookie, err := c.Req.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	var id string
+	if err = s.codec.decode(name, cookie.Value, 0, &id); err != nil {
+		return session, err
+	}
+	values, ok, err := s.backend.Load(id)
+	if err != nil || !ok {
+		return session, err
+	}
+	session.ID = id
+	session.Values = values
+	session.IsNew = false
+	return session, nil
+}
+
+// Save 实现 Store 接口。
+func (s *BackendStore) Save(c *gee.Context, session *Session) error {
+	for _, id := range session.replaced {
+		if err := s.backend.Delete(id); err != nil {
+			return err
+		}
+	}
+	session.replaced = nil
+	if session.Options.MaxAge < 0 {
+		http.SetCookie(c.Writer, session.Options.cookie(session.name, ""))
+		return s.backend.Delete(session.ID)
+	}
+	if err := s.backend.Save(session.ID, session.Values, s.ttl(session)); err != nil {
+		return err
+	}
+	value, err := s.codec.encode(session.name, session.ID)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(c.Writer, session.Options.cookie(session.name, value))
+	session.IsNew = false
+	return nil
+}
+
+// ttl 返回会话数据在服务端的存活时间，会话 Cookie（MaxAge 为 0）默认保存 24 小时。
+func (s *BackendStore) ttl(session *Session) time.Duration {
+	if session.Options.MaxAge > 0 {
+		return time.Duration(session.Options.MaxAge) * time.Second
+	}
+	return 24 * time.Hour
+}
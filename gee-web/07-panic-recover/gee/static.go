@@ -0,0 +1,258 @@
+package gee
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// StaticConfig 是静态文件服务的配置。
+type StaticConfig struct {
+	Root          fs.FS  // 文件系统，例如 os.DirFS("./public") 或 embed.FS
+	Browse        bool   // 目录中没有 Index 文件时，是否列出目录内容
+	Index         string // 目录的默认文件，默认为 "index.html"
+	SPA           bool   // 找不到没有扩展名的路径时返回根目录的 Index，用于单页应用的前端路由
+	Precompressed bool   // 客户端支持时，优先返回同名的 .br 或 .gz 预压缩文件
+}
+
+// precompressedEncodings 是支持的预压缩文件扩展名及对应的 Content-Encoding，按优先级排列。
+var precompressedEncodings = []struct{ encoding, ext string }{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// Static 将本地目录 root 映射到 relativePath 下，不列出目录内容。
+// 参数:
+//   - relativePath: 相对于分组前缀的 URL 路径。
+//   - root: 本地目录。
+func (group *RouterGroup) Static(relativePath string, root string) {
+	group.StaticWithConfig(relativePath, StaticConfig{Root: os.DirFS(root)})
+}
+
+// StaticFS 将文件系统 fsys（例如 embed.FS）映射到 relativePath 下，不列出目录内容。
+// 参数:
+//   - relativePath: 相对于分组前缀的 URL 路径。
+//   - fsys: 文件系统。
+func (group *RouterGroup) StaticFS(relativePath string, fsys fs.FS) {
+	group.StaticWithConfig(relativePath, StaticConfig{Root: fsys})
+}
+
+// StaticFile 将单个本地文件 filePath 映射到 relativePath。
+// 参数:
+//   - relativePath: 相对于分组前缀的 URL 路径。
+//   - filePath: 本地文件路径。
+func (group *RouterGroup) StaticFile(relativePath, filePath string) {
+	dir, name := filepath.Split(filePath)
+	if dir == "" {
+		dir = "."
+	}
+	config := &StaticConfig{Root: os.DirFS(dir)}
+	handler := func(c *Context) {
+		config.serveFile(c, name)
+	}
+	group.GET(relativePath, handler).Hide()
+	group.HEAD(relativePath, handler).Hide()
+}
+
+// StaticWithConfig 按配置将文件系统映射到 relativePath 下。
+// 支持 ETag、Last-Modified、Range 和条件请求。
+func (group *RouterGroup) StaticWithConfig(relativePath string, config StaticConfig) {
+	if config.Index == "" {
+		config.Index = "index.html"
+	}
+	if config.Browse {
+		debugPrintWarning("Directory listing is enabled for %s.", path.Join(group.prefix, relativePath))
+	}
+	handler := func(c *Context) {
+		config.serveFile(c, c.Param("filepath"))
+	}
+	urlPattern := path.Join(relativePath, "/*filepath")
+	// Register GET and HEAD handlers
+	group.GET(urlPattern, handler).Hide()
+	group.HEAD(urlPattern, handler).Hide()
+	// 不带 "/" 的目录路径（例如 "/assets"）重定向到 "/assets/"；映射到 "/" 时不注册，避免占用用户自己的 "/" 路由
+	if p := path.Clean("/" + relativePath); p != "/" {
+		group.GET(p, handler).Hide()
+		group.HEAD(p, handler).Hide()
+	}
+}
+
+// serveFile 返回文件系统中 name 对应的文件或目录。
+func (config *StaticConfig) serveFile(c *Context, name string) {
+	name = cleanName(name)
+	f, err := config.Root.Open(name)
+	if err != nil {
+		config.notFound(c, name)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		config.notFound(c, name)
+		return
+	}
+	if !info.IsDir() {
+		config.serveContent(c, name, f, info)
+		return
+	}
+
+	// 目录需要以 "/" 结尾，保证目录列表和 index 中的相对链接正确
+	if !strings.HasSuffix(c.Req.URL.Path, "/") {
+		target := c.Req.URL.Path + "/"
+		if c.Req.URL.RawQuery != "" {
+			target += "?" + c.Req.URL.RawQuery
+		}
+		c.SetHeader("Location", target)
+		c.Status(http.StatusMovedPermanently)
+		return
+	}
+	if config.Index != "" {
+		index := path.Join(name, config.Index)
+		if ff, err := config.Root.Open(index); err == nil {
+			defer ff.Close()
+			if fi, err := ff.Stat(); err == nil && !fi.IsDir() {
+				config.serveContent(c, index, ff, fi)
+				return
+			}
+		}
+	}
+	if !config.Browse {
+		config.notFound(c, name)
+		return
+	}
+	config.listDir(c, name)
+}
+
+// serveContent 使用 http.ServeContent 返回文件内容，由其处理 Range 和条件请求。
+func (config *StaticConfig) serveContent(c *Context, name string, f fs.File, info fs.FileInfo) {
+	header := c.Writer.Header()
+	if config.Precompressed {
+		header.Add("Vary", "Accept-Encoding")
+		accept := parseAccept(c.Req.Header.Get("Accept-Encoding"))
+		for _, pc := range precompressedEncodings {
+			if !acceptsEncoding(accept, pc.encoding) {
+				continue
+			}
+			cf, err := config.Root.Open(name + pc.ext)
+			if err != nil {
+				continue
+			}
+			defer cf.Close()
+			ci, err := cf.Stat()
+			if err != nil || ci.IsDir() {
+				continue
+			}
+			contentType := mime.TypeByExtension(path.Ext(name))
+			if contentType == "" {
+				contentType = "application/octet-stream"
+			}
+			header.Set("Content-Type", contentType)
+			header.Set("Content-Encoding", pc.encoding)
+			serveContent(c, path.Base(name), cf, ci, "-"+pc.encoding)
+			return
+		}
+	}
+	serveContent(c, path.Base(name), f, info, "")
+}
+
+// notFound 返回 404；开启 SPA 时，没有扩展名的路径返回根目录的 Index。
+func (config *StaticConfig) notFound(c *Context, name string) {
+	if config.SPA && path.Ext(name) == "" {
+		if f, err := config.Root.Open(config.Index); err == nil {
+			defer f.Close()
+			if info, err := f.Stat(); err == nil && !info.IsDir() {
+				config.serveContent(c, config.Index, f, info)
+				return
+			}
+		}
+	}
+	c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.Path)
+}
+
+// listDir 以 HTML 列出目录内容。
+func (config *StaticConfig) listDir(c *Context, name string) {
+	entries, err := fs.ReadDir(config.Root, name)
+	if err != nil {
+		c.Fail(http.StatusInternalServerError, "Error reading directory")
+		return
+	}
+	var buf bytes.Buffer
+	buf.WriteString("<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n")
+	for _, entry := range entries {
+		entryName := entry.Name()
+		if entry.IsDir() {
+			entryName += "/"
+		}
+		link := url.URL{Path: entryName}
+		fmt.Fprintf(&buf, "<a href=\"%s\">%s</a>\n", link.String(), html.EscapeString(entryName))
+	}
+	buf.WriteString("</pre>\n")
+	c.HTML(http.StatusOK, buf.String())
+}
+
+// serveContent 返回文件内容，etagSuffix 用于区分同一文件的不同编码。
+// fs.File 不支持 Seek 时会先将文件读入内存。
+func serveContent(c *Context, name string, f fs.File, info fs.FileInfo, etagSuffix string) {
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			c.Fail(http.StatusInternalServerError, err.Error())
+			return
+		}
+		rs = bytes.NewReader(b)
+	}
+	if c.Writer.Header().Get("ETag") == "" {
+		c.SetHeader("ETag", fmt.Sprintf(`"%x-%x%s"`, info.ModTime().UnixNano(), info.Size(), etagSuffix))
+	}
+	sw := &statusWriter{ResponseWriter: c.Writer}
+	http.ServeContent(sw, c.Req, name, info.ModTime(), rs)
+	c.StatusCode = sw.status
+}
+
+// cleanName 将 URL 路径转换为 fs.FS 可以接受的文件名，去掉 "." 和 ".." 等元素。
+func cleanName(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// statusWriter 记录写出的状态码，用于将直接写入 http.ResponseWriter 的状态同步到 Context。
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader 实现 http.ResponseWriter 接口。
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write 实现 http.ResponseWriter 接口。
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush 实现 http.Flusher 接口。
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
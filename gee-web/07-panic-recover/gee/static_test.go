@@ -0,0 +1,111 @@
+package gee
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func newStaticEngine(t *testing.T) *Engine {
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"index.html":      {Data: []byte("<h1>index</h1>"), ModTime: modTime},
+		"app.js":          {Data: []byte("console.log('gee')"), ModTime: modTime},
+		"app.js.gz":       {Data: []byte("gzipped"), ModTime: modTime},
+		"docs/readme.txt": {Data: []byte("0123456789"), ModTime: modTime},
+	}
+	file := filepath.Join(t.TempDir(), "robots.txt")
+	if err := os.WriteFile(file, []byte("User-agent: *"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := New()
+	r.StaticFS("/assets", fsys)
+	r.StaticWithConfig("/browse", StaticConfig{Root: fsys, Browse: true})
+	r.StaticWithConfig("/app", StaticConfig{Root: fsys, SPA: true, Precompressed: true})
+	r.StaticFile("/robots.txt", file)
+	return r
+}
+
+func TestStaticFS(t *testing.T) {
+	r := newStaticEngine(t)
+	w := performRequest(r, http.MethodGet, "/assets/docs/readme.txt", "")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Body.String() != "0123456789" || etag == "" || w.Header().Get("Last-Modified") == "" {
+		t.Fatalf("unexpected response %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+	if w = performRequest(r, http.MethodGet, "/assets/docs/readme.txt", "", "If-None-Match", etag); w.Code != http.StatusNotModified {
+		t.Fatalf("expect 304, got %d", w.Code)
+	}
+	if w = performRequest(r, http.MethodGet, "/assets/docs/readme.txt", "", "Range", "bytes=2-4"); w.Code != http.StatusPartialContent || w.Body.String() != "234" {
+		t.Fatalf("expect 206 234, got %d %q", w.Code, w.Body.String())
+	}
+	if w = performRequest(r, http.MethodGet, "/assets/", ""); w.Body.String() != "<h1>index</h1>" {
+		t.Fatalf("directory should serve index.html, got %q", w.Body.String())
+	}
+	if w = performRequest(r, http.MethodGet, "/assets/docs/", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("directory listing should be disabled, got %d", w.Code)
+	}
+	if w = performRequest(r, http.MethodGet, "/assets/../index.html", ""); w.Body.String() != "<h1>index</h1>" {
+		t.Fatalf("path should be cleaned, got %d", w.Code)
+	}
+	if w = performRequest(r, http.MethodGet, "/assets/missing.js", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expect 404, got %d", w.Code)
+	}
+}
+
+func TestStaticBrowse(t *testing.T) {
+	r := newStaticEngine(t)
+	if w := performRequest(r, http.MethodGet, "/browse/docs", ""); w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/browse/docs/" {
+		t.Fatalf("expect redirect to /browse/docs/, got %d", w.Code)
+	}
+	w := performRequest(r, http.MethodGet, "/browse/docs/", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `<a href="readme.txt">readme.txt</a>`) {
+		t.Fatalf("expect directory listing, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestStaticPrecompressedAndSPA(t *testing.T) {
+	r := newStaticEngine(t)
+	w := performRequest(r, http.MethodGet, "/app/app.js", "", "Accept-Encoding", "gzip")
+	if w.Body.String() != "gzipped" || w.Header().Get("Content-Encoding") != "gzip" ||
+		!strings.HasPrefix(w.Header().Get("Content-Type"), "text/javascript") {
+		t.Fatalf("expect precompressed file, got %q %v", w.Body.String(), w.Header())
+	}
+	if w = performRequest(r, http.MethodGet, "/app/app.js", ""); w.Body.String() != "console.log('gee')" {
+		t.Fatalf("expect original file, got %q", w.Body.String())
+	}
+	if w = performRequest(r, http.MethodGet, "/app/users/1", ""); w.Code != http.StatusOK || w.Body.String() != "<h1>index</h1>" {
+		t.Fatalf("SPA route should fall back to index.html, got %d", w.Code)
+	}
+	if w = performRequest(r, http.MethodGet, "/app/missing.css", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("missing asset should be 404, got %d", w.Code)
+	}
+}
+
+func TestStaticFile(t *testing.T) {
+	r := newStaticEngine(t)
+	if w := performRequest(r, http.MethodGet, "/robots.txt", ""); w.Body.String() != "User-agent: *" {
+		t.Fatalf("unexpected body %q", w.Body.String())
+	}
+}
+
+func TestStaticRoot(t *testing.T) {
+	r := New()
+	r.GET("/", func(c *Context) { c.String(http.StatusOK, "home") })
+	r.Static("/", ".")
+
+	if w := performRequest(r, http.MethodGet, "/", ""); w.Body.String() != "home" {
+		t.Fatalf("static root should not take over GET /, got %q", w.Body.String())
+	}
+	if w := performRequest(r, http.MethodGet, "/static_test.go", ""); w.Code != http.StatusOK {
+		t.Fatalf("files under the root should be served, got %d", w.Code)
+	}
+	if w := performRequest(newStaticEngine(t), http.MethodGet, "/assets", ""); w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/assets/" {
+		t.Fatalf("bare directory path should redirect, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+}
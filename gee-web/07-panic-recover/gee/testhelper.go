@@ -0,0 +1,17 @@
+package gee
+
+import "net/http"
+
+// CreateTestContext 创建一个不经过路由的 Context 及其所属的 Engine，用于单元测试中间件。
+// handlers 是依次执行的处理链，调用 c.Next() 开始执行。
+// 参数:
+//   - w: 响应写入器，通常为 httptest.ResponseRecorder。
+//   - req: 请求。
+//   - handlers: 处理链。
+func CreateTestContext(w http.ResponseWriter, req *http.Request, handlers ...HandlerFunc) (*Context, *Engine) {
+	engine := newEngine()
+	c := newContext(w, req)
+	c.engine = engine
+	c.handlers = handlers
+	return c, engine
+}
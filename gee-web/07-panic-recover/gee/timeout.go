@@ -0,0 +1,164 @@
+package gee
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TimeoutConfig 是超时中间件的配置。
+type TimeoutConfig struct {
+	Timeout time.Duration // 处理超时时间
+	Status  int           // 超时后返回的状态码，默认为 503，也可以设置为 504
+	Message string        // 超时后返回的错误信息，默认为状态码对应的描述
+}
+
+// Timeout 返回超时中间件，超时后返回 503。
+// 参数:
+//   - d: 处理超时时间。
+func Timeout(d time.Duration) HandlerFunc {
+	return TimeoutWithConfig(TimeoutConfig{Timeout: d})
+}
+
+// TimeoutWithConfig 返回超时中间件。
+// 后续的处理函数在新的 goroutine 中执行，c.Req 的 Context 带有截止时间，处理函数应当在其被取消时尽快返回。
+// 处理函数的输出先写入缓冲区，按时完成时再写出到客户端；超时后缓冲区被丢弃，之后的写入返回 http.ErrHandlerTimeout。
+func TimeoutWithConfig(config TimeoutConfig) HandlerFunc {
+	if config.Status == 0 {
+		config.Status = http.StatusServiceUnavailable
+	}
+	if config.Message == "" {
+		config.Message = http.StatusText(config.Status)
+	}
+	return func(c *Context) {
+		ctx, cancel := context.WithTimeout(c.Req.Context(), config.Timeout)
+		defer cancel()
+
+		// tw 在写入时检查 ctx，截止时间一到就拒绝之后的写入，而不是等到下面的 select 选中 ctx.Done()；
+		// 否则同样在等待 ctx.Done() 的处理函数可能抢先写入并返回，迟到的响应会被当作正常响应发出
+		tw := &timeoutWriter{ctx: ctx, header: make(http.Header)}
+		// 后续处理函数使用独立的 Context，避免与当前 goroutine 竞争 index 等字段
+		tc := &Context{
+			Writer:   tw,
+			Req:      c.Req.WithContext(ctx),
+			Path:     c.Path,
+			Method:   c.Method,
+			Params:   c.Params,
+			fullPath: c.fullPath,
+			handlers: c.handlers,
+			index:    c.index,
+			engine:   c.engine,
+			Keys:     c.copyKeys(),
+			sameSite: c.sameSite,
+		}
+		// 后续处理函数由 tc 执行
+		c.index = len(c.handlers)
+
+		done := make(chan struct{})
+		panicChan := make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicChan <- p
+				}
+			}()
+			tc.Next()
+			close(done)
+		}()
+
+		select {
+		case p := <-panicChan:
+			// 交给外层的 Recovery 处理
+			panic(p)
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			if ctx.Err() != nil {
+				// 处理函数在超时之后才返回
+				tw.timedOut = true
+				c.Fail(config.Status, config.Message)
+				return
+			}
+			dst := c.Writer.Header()
+			for k, v := range tw.header {
+				dst[k] = v
+			}
+			keys := tc.copyKeys()
+			c.mu.Lock()
+			c.Keys = keys
+			c.mu.Unlock()
+			if tc.IsAborted() {
+				c.Abort()
+			}
+			c.Status(tw.status())
+			c.Writer.Write(tw.buf.Bytes())
+		case <-ctx.Done():
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			tw.timedOut = true
+			c.Fail(config.Status, config.Message)
+		}
+	}
+}
+
+// copyKeys 返回 Keys 的浅拷贝。
+func (c *Context) copyKeys() map[string]interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.Keys == nil {
+		return nil
+	}
+	keys := make(map[string]interface{}, len(c.Keys))
+	for k, v := range c.Keys {
+		keys[k] = v
+	}
+	return keys
+}
+
+// timeoutWriter 缓冲处理函数的输出，超时后丢弃之后的写入。
+type timeoutWriter struct {
+	ctx      context.Context
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	code     int
+	timedOut bool
+}
+
+// Header 实现 http.ResponseWriter 接口。
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// Write 实现 http.ResponseWriter 接口。
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.ctx.Err() != nil {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	return tw.buf.Write(b)
+}
+
+// WriteHeader 实现 http.ResponseWriter 接口。
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.ctx.Err() != nil || tw.code != 0 {
+		return
+	}
+	tw.code = code
+}
+
+// status 返回处理函数写入的状态码，未写入时为 200。
+func (tw *timeoutWriter) status() int {
+	if tw.code == 0 {
+		return http.StatusOK
+	}
+	return tw.code
+}
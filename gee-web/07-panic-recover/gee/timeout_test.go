@@ -0,0 +1,52 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	r := New()
+	late := make(chan error, 1)
+	r.Use(Timeout(50 * time.Millisecond))
+	r.GET("/fast", func(c *Context) {
+		c.Set("user", "geektutu")
+		c.SetHeader("X-Fast", "1")
+		c.String(http.StatusCreated, "fast")
+	})
+	r.GET("/slow", func(c *Context) {
+		<-c.Req.Context().Done()
+		_, err := c.Writer.Write([]byte("late"))
+		late <- err
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if w.Code != http.StatusCreated || w.Body.String() != "fast" || w.Header().Get("X-Fast") != "1" {
+		t.Fatalf("fast handler output should be kept, got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("slow handler should time out with 503, got %d", w.Code)
+	}
+	if err := <-late; err != http.ErrHandlerTimeout {
+		t.Fatalf("late write should fail with ErrHandlerTimeout, got %v", err)
+	}
+}
+
+func TestTimeoutPanic(t *testing.T) {
+	r := New()
+	r.Use(Recovery(), TimeoutWithConfig(TimeoutConfig{Timeout: time.Second, Status: http.StatusGatewayTimeout}))
+	r.GET("/panic", func(c *Context) {
+		panic("boom")
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("panic should be recovered with 500, got %d", w.Code)
+	}
+}
@@ -0,0 +1,143 @@
+package tracing
+
+import (
+	"sync"
+	"time"
+)
+
+// SpanData 是结束后的 span 的只读快照，交给 Exporter 导出。
+type SpanData struct {
+	Name        string
+	SpanContext SpanContext
+	ParentID    SpanID // 父 span 的 ID，根 span 为全 0
+	RequestID   string
+	Start       time.Time
+	End         time.Time
+	Attributes  map[string]string
+	Error       string
+}
+
+// Exporter 导出已结束的 span，实现需要保证并发安全。
+type Exporter interface {
+	Export(span SpanData)
+}
+
+// Span 表示链路中的一次操作，例如处理一个请求或调用一次下游服务。
+type Span struct {
+	mu       sync.Mutex
+	data     SpanData
+	ended    bool
+	exporter Exporter
+}
+
+// newSpan 创建一个 span，parent 无效时开启一条新的链路。
+func newSpan(name string, parent SpanContext, requestID string, exporter Exporter) *Span {
+	sc := SpanContext{SpanID: newSpanID(), Flags: FlagSampled}
+	var parentID SpanID
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Flags = parent.Flags
+		sc.TraceState = parent.TraceState
+		parentID = parent.SpanID
+	} else {
+		sc.TraceID = newTraceID()
+	}
+	return &Span{
+		data: SpanData{
+			Name:        name,
+			SpanContext: sc,
+			ParentID:    parentID,
+			RequestID:   requestID,
+			Start:       time.Now(),
+			Attributes:  make(map[string]string),
+		},
+		exporter: exporter,
+	}
+}
+
+// SpanContext 返回 span 的链路上下文。
+func (s *Span) SpanContext() SpanContext {
+	return s.data.SpanContext
+}
+
+// SetName 修改 span 的名称。
+func (s *Span) SetName(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Name = name
+}
+
+// SetAttribute 设置 span 的属性。
+func (s *Span) SetAttribute(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attributes[key] = value
+}
+
+// SetError 记录 span 执行过程中的错误。
+func (s *Span) SetError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Error = err.Error()
+}
+
+// StartChild 创建一个子 span，通常包裹一次下游调用，使用完毕后必须调用 End。
+func (s *Span) StartChild(name string) *Span {
+	return newSpan(name, s.data.SpanContext, s.data.RequestID, s.exporter)
+}
+
+// End 结束 span，被采样的 span 会交给 Exporter 导出。重复调用无效。
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	data.Attributes = make(map[string]string, len(s.data.Attributes))
+	for k, v := range s.data.Attributes {
+		data.Attributes[k] = v
+	}
+	s.mu.Unlock()
+
+	if s.exporter != nil && data.SpanContext.IsSampled() {
+		s.exporter.Export(data)
+	}
+}
+
+// InMemoryExporter 将 span 保存在内存中，主要用于测试。
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+// NewInMemoryExporter 创建一个 InMemoryExporter。
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// Export 实现 Exporter 接口。
+func (e *InMemoryExporter) Export(span SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans 返回已导出的 span，按结束顺序排列。
+func (e *InMemoryExporter) Spans() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]SpanData(nil), e.spans...)
+}
+
+// Reset 清空已导出的 span。
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
@@ -0,0 +1,161 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// W3C Trace Context 使用的请求头。
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+)
+
+// ErrInvalidTraceparent 表示 traceparent 头格式错误。
+var ErrInvalidTraceparent = errors.New("tracing: invalid traceparent")
+
+// TraceID 是 16 字节的链路 ID。
+type TraceID [16]byte
+
+// String 返回链路 ID 的十六进制表示。
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// IsValid 判断链路 ID 是否有效（不全为 0）。
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+// SpanID 是 8 字节的 span ID。
+type SpanID [8]byte
+
+// String 返回 span ID 的十六进制表示。
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// IsValid 判断 span ID 是否有效（不全为 0）。
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// FlagSampled 表示该链路被采样，需要导出。
+const FlagSampled byte = 0x01
+
+// SpanContext 是在服务之间传播的链路上下文。
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte   // trace-flags，目前只定义了 FlagSampled
+	TraceState string // tracestate 头的原始值，由各厂商自行定义
+	Remote     bool   // 是否从请求头中解析得到
+}
+
+// IsValid 判断链路上下文是否有效。
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// IsSampled 判断链路是否被采样。
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags&FlagSampled != 0
+}
+
+// Traceparent 返回 traceparent 头的值，格式为 "00-<trace-id>-<span-id>-<flags>"。
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// ParseTraceparent 解析 traceparent 头。
+// 版本 00 必须严格为 55 个字符；更高的版本只解析前 4 个字段，以兼容未来的扩展。
+func ParseTraceparent(s string) (SpanContext, error) {
+	var sc SpanContext
+	s = strings.TrimSpace(s)
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return sc, ErrInvalidTraceparent
+	}
+	version, err := decodeHex(s[0:2], 1)
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(s) != 55) || (len(s) > 55 && s[55] != '-') {
+		return sc, ErrInvalidTraceparent
+	}
+	traceID, err := decodeHex(s[3:35], 16)
+	if err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+	spanID, err := decodeHex(s[36:52], 8)
+	if err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+	flags, err := decodeHex(s[53:55], 1)
+	if err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	sc.Flags = flags[0]
+	sc.Remote = true
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	return sc, nil
+}
+
+// Extract 从请求头中解析链路上下文，traceparent 无效时同时忽略 tracestate。
+func Extract(header http.Header) (SpanContext, bool) {
+	sc, err := ParseTraceparent(header.Get(TraceparentHeader))
+	if err != nil {
+		return SpanContext{}, false
+	}
+	sc.TraceState = strings.Join(header.Values(TracestateHeader), ",")
+	return sc, true
+}
+
+// Inject 将链路上下文写入请求头，用于调用下游服务。
+func Inject(header http.Header, sc SpanContext) {
+	header.Set(TraceparentHeader, sc.Traceparent())
+	if sc.TraceState != "" {
+		header.Set(TracestateHeader, sc.TraceState)
+	} else {
+		header.Del(TracestateHeader)
+	}
+}
+
+// decodeHex 解码小写十六进制字符串，要求解码后为 n 字节。
+func decodeHex(s string, n int) ([]byte, error) {
+	if strings.ToLower(s) != s {
+		return nil, ErrInvalidTraceparent
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != n {
+		return nil, ErrInvalidTraceparent
+	}
+	return b, nil
+}
+
+// newTraceID 生成随机的链路 ID。
+func newTraceID() (id TraceID) {
+	for !id.IsValid() {
+		randomRead(id[:])
+	}
+	return
+}
+
+// newSpanID 生成随机的 span ID。
+func newSpanID() (id SpanID) {
+	for !id.IsValid() {
+		randomRead(id[:])
+	}
+	return
+}
+
+// randomRead 使用密码学安全的随机数填充 b。
+func randomRead(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic("tracing: failed to generate id: " + err.Error())
+	}
+}
@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"encoding/hex"
+	"gee-web/gee-web/07-panic-recover/gee"
+	"net/http"
+	"strconv"
+)
+
+// SpanKey 是当前请求的 span 在 gee.Context 中保存时使用的键。
+const SpanKey = "gee-web/tracing"
+
+// Config 是链路追踪中间件的配置。
+type Config struct {
+	// Exporter 导出已结束的 span，为 nil 时只传播链路上下文、不导出。
+	Exporter Exporter
+	// RequestIDHeader 是请求 ID 使用的请求头，默认为 "X-Request-ID"。
+	RequestIDHeader string
+}
+
+// New 返回链路追踪中间件。
+// 中间件沿用或生成 X-Request-ID，解析或创建 traceparent/tracestate，为请求创建一个 span，
+// 并将这些信息保存到 Context（供 Logger 输出）和响应头中。
+func New(config Config) gee.HandlerFunc {
+	if config.RequestIDHeader == "" {
+		config.RequestIDHeader = "X-Request-ID"
+	}
+	return func(c *gee.Context) {
+		requestID := c.Req.Header.Get(config.RequestIDHeader)
+		if !validRequestID(requestID) {
+			var b [16]byte
+			randomRead(b[:])
+			requestID = hex.EncodeToString(b[:])
+		}
+		parent, _ := Extract(c.Req.Header)
+		span := newSpan(c.Method+" "+c.Path, parent, requestID, config.Exporter)
+		span.SetAttribute("http.method", c.Method)
+		span.SetAttribute("http.target", c.Req.RequestURI)
+
+		sc := span.SpanContext()
+		c.Set(SpanKey, span)
+		c.Set(gee.RequestIDKey, requestID)
+		c.Set(gee.TraceIDKey, sc.TraceID.String())
+		c.SetHeader(config.RequestIDHeader, requestID)
+		Inject(c.Writer.Header(), sc)
+
+		defer func() {
+			span.SetAttribute("http.status_code", strconv.Itoa(c.StatusCode))
+			span.End()
+		}()
+		c.Next()
+	}
+}
+
+// SpanFromContext 返回当前请求的 span，未经过链路追踪中间件时返回 nil。
+func SpanFromContext(c *gee.Context) *Span {
+	v, _ := c.Get(SpanKey)
+	span, _ := v.(*Span)
+	return span
+}
+
+// StartSpan 在当前请求的 span 下创建一个子 span，用于包裹下游调用。
+// 未经过链路追踪中间件时开启一条新的链路。使用完毕后必须调用 End。
+func StartSpan(c *gee.Context, name string) *Span {
+	if parent := SpanFromContext(c); parent != nil {
+		return parent.StartChild(name)
+	}
+	return newSpan(name, SpanContext{}, c.GetString(gee.RequestIDKey), nil)
+}
+
+// InjectRequest 将 span 的链路上下文和请求 ID 写入发往下游服务的请求。
+func InjectRequest(req *http.Request, span *Span) {
+	Inject(req.Header, span.SpanContext())
+	if span.data.RequestID != "" {
+		req.Header.Set("X-Request-ID", span.data.RequestID)
+	}
+}
+
+// validRequestID 判断客户端传入的请求 ID 是否可以沿用：非空、不超过 128 个字符且只包含可见 ASCII 字符。
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
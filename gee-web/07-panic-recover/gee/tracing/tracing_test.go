@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"gee-web/gee-web/07-panic-recover/gee"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil || sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" ||
+		sc.SpanID.String() != "00f067aa0ba902b7" || !sc.IsSampled() {
+		t.Fatalf("unexpected span context %+v, %v", sc, err)
+	}
+	if sc.Traceparent() != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Fatalf("unexpected traceparent %s", sc.Traceparent())
+	}
+	if _, err = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future"); err != nil {
+		t.Fatalf("future version should be accepted, got %v", err)
+	}
+	for _, s := range []string{
+		"",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, err = ParseTraceparent(s); err == nil {
+			t.Fatalf("%q should be rejected", s)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	r := gee.New()
+	r.Use(New(Config{Exporter: exporter}))
+	r.GET("/", func(c *gee.Context) {
+		child := StartSpan(c, "downstream")
+		req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+		InjectRequest(req, child)
+		child.End()
+		c.String(http.StatusOK, "%s %s", c.GetString(gee.RequestIDKey), req.Header.Get(TraceparentHeader))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	req.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set(TracestateHeader, "congo=t61rcWkgMzE")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Header().Get("X-Request-ID") != "req-1" || w.Header().Get(TracestateHeader) != "congo=t61rcWkgMzE" {
+		t.Fatalf("request id and tracestate should be echoed, got %v", w.Header())
+	}
+	spans := exporter.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("expect 2 spans, got %d", len(spans))
+	}
+	child, server := spans[0], spans[1]
+	if server.SpanContext.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" ||
+		server.ParentID.String() != "00f067aa0ba902b7" || server.Attributes["http.status_code"] != "200" {
+		t.Fatalf("unexpected server span %+v", server)
+	}
+	if child.ParentID != server.SpanContext.SpanID || child.RequestID != "req-1" {
+		t.Fatalf("unexpected child span %+v", child)
+	}
+	if want := "req-1 " + child.SpanContext.Traceparent(); w.Body.String() != want {
+		t.Fatalf("expect %q, got %q", want, w.Body.String())
+	}
+
+	exporter.Reset()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if len(exporter.Spans()) != 0 {
+		t.Fatal("unsampled trace should not be exported")
+	}
+	if len(w.Header().Get("X-Request-ID")) != 32 {
+		t.Fatalf("request id should be generated, got %q", w.Header().Get("X-Request-ID"))
+	}
+}
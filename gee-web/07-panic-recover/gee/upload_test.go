@@ -0,0 +1,109 @@
+package gee
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newMultipartRequest 构造一个包含 file 字段的上传请求。
+func newMultipartRequest(t *testing.T, path, filename, content string) *http.Request {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte(content))
+	mw.WriteField("name", "gee")
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestUpload(t *testing.T) {
+	dir := t.TempDir()
+	r := New()
+	r.MaxMultipartMemory = 8
+	r.POST("/upload", func(c *Context) {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			c.Fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		if err = c.SaveUploadedFile(fh, filepath.Join(dir, "sub", fh.Filename)); err != nil {
+			c.Fail(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.String(http.StatusOK, "%s %d %s", fh.Filename, fh.Size, c.PostForm("name"))
+	})
+	r.POST("/stream", func(c *Context) {
+		mr, err := c.MultipartReader()
+		if err != nil {
+			c.Fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		var names []string
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				c.Fail(http.StatusBadRequest, err.Error())
+				return
+			}
+			n, _ := io.Copy(io.Discard, part)
+			names = append(names, part.FormName()+":"+strconv.FormatInt(n, 10))
+		}
+		c.String(http.StatusOK, "%s", strings.Join(names, ","))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newMultipartRequest(t, "/upload", "a.txt", "hello gee upload"))
+	if w.Code != http.StatusOK || w.Body.String() != "a.txt 16 gee" {
+		t.Fatalf("unexpected response %d %q", w.Code, w.Body.String())
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "sub", "a.txt")); string(b) != "hello gee upload" {
+		t.Fatalf("unexpected saved file %q", b)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newMultipartRequest(t, "/stream", "b.txt", "12345"))
+	if w.Body.String() != "file:5,name:3" {
+		t.Fatalf("unexpected parts %q", w.Body.String())
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	r := New()
+	r.Use(BodyLimit(10))
+	var readErr error
+	r.POST("/", func(c *Context) {
+		_, readErr = io.ReadAll(c.Req.Body)
+		c.String(http.StatusOK, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("x", 11))))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expect 413, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("x", 11)))
+	req.ContentLength = -1
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	var maxErr *http.MaxBytesError
+	if !errors.As(readErr, &maxErr) {
+		t.Fatalf("expect MaxBytesError for chunked body, got %v", readErr)
+	}
+}
@@ -0,0 +1,84 @@
+package gee
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// anyMethods 是 Any 和 Mount 注册的请求方法。
+var anyMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodHead, http.MethodOptions,
+}
+
+// WrapH 将 http.Handler 包装为 HandlerFunc。
+// 参数:
+//   - h: 标准库的处理器，例如 http.FileServer 或第三方的监控面板。
+func WrapH(h http.Handler) HandlerFunc {
+	return func(c *Context) {
+		sw := &statusWriter{ResponseWriter: c.Writer}
+		h.ServeHTTP(sw, c.Req)
+		c.StatusCode = sw.status
+	}
+}
+
+// WrapF 将 http.HandlerFunc 包装为 HandlerFunc。
+// 参数:
+//   - f: 标准库的处理函数，例如 pprof.Index。
+func WrapF(f http.HandlerFunc) HandlerFunc {
+	return WrapH(f)
+}
+
+// WrapMiddleware 将 gee 的中间件转换为标准库形式的中间件 func(http.Handler) http.Handler，
+// 以便在 gee 之外（例如 http.ServeMux）复用 Logger、Recovery、CORS 等中间件。
+// 中间件中断处理链（例如认证失败）时，next 不会被调用。
+func WrapMiddleware(middleware HandlerFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			c := newContext(w, req)
+			c.handlers = []HandlerFunc{middleware, func(c *Context) {
+				next.ServeHTTP(c.Writer, c.Req)
+			}}
+			c.Next()
+		})
+	}
+}
+
+// Any 为所有常用的请求方法注册同一个处理函数。
+// 参数:
+//   - pattern: 请求路径模式。
+//   - handler: 处理该请求的HandlerFunc。
+func (group *RouterGroup) Any(pattern string, handler HandlerFunc) {
+	for _, method := range anyMethods {
+		group.addRoute(method, pattern, handler)
+	}
+}
+
+// Mount 将 http.Handler 挂载到 prefix 下，转发前从请求路径中去掉分组前缀和 prefix。
+// h 可以是另一个 *Engine，它会作为子应用继续执行自己的中间件和 NoRoute。
+// 参数:
+//   - prefix: 相对于分组前缀的挂载路径，例如 "/debug/pprof"。
+//   - h: 被挂载的处理器。
+func (group *RouterGroup) Mount(prefix string, h http.Handler) {
+	absolutePrefix := strings.TrimSuffix(path.Join(group.prefix, prefix), "/")
+	handler := func(c *Context) {
+		req := new(http.Request)
+		*req = *c.Req
+		u := *c.Req.URL
+		u.Path = strings.TrimPrefix(u.Path, absolutePrefix)
+		if !strings.HasPrefix(u.Path, "/") {
+			u.Path = "/" + u.Path
+		}
+		u.RawPath = ""
+		req.URL = &u
+
+		sw := &statusWriter{ResponseWriter: c.Writer}
+		h.ServeHTTP(sw, req)
+		c.StatusCode = sw.status
+	}
+	for _, method := range anyMethods {
+		group.addRoute(method, prefix, handler).Hide()
+		group.addRoute(method, path.Join(prefix, "/*filepath"), handler).Hide()
+	}
+}
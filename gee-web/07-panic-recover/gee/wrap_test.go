@@ -0,0 +1,81 @@
+package gee
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	r := New()
+	r.GET("/f", WrapF(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("wrapped"))
+	}))
+	var status int
+	r.Use(func(c *Context) {
+		c.Next()
+		status = c.StatusCode
+	})
+	if w := performRequest(r, http.MethodGet, "/f", ""); w.Body.String() != "wrapped" || status != http.StatusAccepted {
+		t.Fatalf("unexpected response %q, status %d", w.Body.String(), status)
+	}
+}
+
+func TestMount(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("mux " + req.URL.Path))
+	})
+
+	sub := New()
+	sub.Use(func(c *Context) {
+		c.SetHeader("X-Sub", "1")
+	})
+	sub.GET("/hello", func(c *Context) {
+		c.String(http.StatusOK, "sub hello")
+	})
+	sub.NoRoute(func(c *Context) {
+		c.String(http.StatusNotFound, "sub 404 %s", c.Path)
+	})
+
+	r := New()
+	v1 := r.Group("/v1")
+	v1.Mount("/mux", mux)
+	r.Mount("/sub", sub)
+
+	if w := performRequest(r, http.MethodPost, "/v1/mux/a/b/", ""); w.Body.String() != "mux /a/b/" {
+		t.Fatalf("prefix should be stripped, got %q", w.Body.String())
+	}
+	if w := performRequest(r, http.MethodGet, "/v1/mux", ""); w.Body.String() != "mux /" {
+		t.Fatalf("mount root should map to /, got %q", w.Body.String())
+	}
+	if w := performRequest(r, http.MethodGet, "/sub/hello", ""); w.Body.String() != "sub hello" || w.Header().Get("X-Sub") != "1" {
+		t.Fatalf("sub engine should run its middleware, got %q", w.Body.String())
+	}
+	if w := performRequest(r, http.MethodGet, "/sub/missing", ""); w.Code != http.StatusNotFound || w.Body.String() != "sub 404 /missing" {
+		t.Fatalf("sub engine NoRoute should be used, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestWrapMiddleware(t *testing.T) {
+	final := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	h := WrapMiddleware(BasicAuth(Accounts{"admin": "secret"}))(final)
+
+	if w := performRequest(h, http.MethodGet, "/", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expect 401, got %d", w.Code)
+	}
+	w := performRequest(h, http.MethodGet, "/", "", "Authorization", "Basic YWRtaW46c2VjcmV0")
+	if w.Body.String() != "ok" {
+		t.Fatalf("expect ok, got %q", w.Body.String())
+	}
+}
+
+func TestWrapMiddlewareHTMLTemplate(t *testing.T) {
+	h := WrapMiddleware(func(c *Context) { c.HTMLTemplate(http.StatusOK, "index", nil) })(http.NotFoundHandler())
+	w := performRequest(h, http.MethodGet, "/", "")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("HTMLTemplate without an Engine should fail with 500, got %d", w.Code)
+	}
+}
@@ -15,7 +15,7 @@ func main() {
 	// index超过数组长度，触发panic
 	r.GET("/panic", func(c *gee.Context) {
 		names := []string{"yyds"}
-		c.String(http.StatusOK, names[100])
+		c.String(http.StatusOK, "%s", names[100])
 	})
 
 	r.Run(":9999")